@@ -0,0 +1,15 @@
+//go:build !wasm
+
+package orm
+
+import "time"
+
+// unixNow returns the current unix time in seconds. It is the default DB clock.
+func unixNow() int64 {
+	return time.Now().Unix()
+}
+
+// sleepMillis waits ms milliseconds. It is the default DB sleep.
+func sleepMillis(ms int64) {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+}
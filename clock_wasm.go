@@ -0,0 +1,25 @@
+//go:build wasm
+
+package orm
+
+import "syscall/js"
+
+// unixNow returns the current unix time in seconds. It is the default DB clock;
+// in the browser it reads Date.now() to keep the time package out of the binary.
+func unixNow() int64 {
+	return int64(js.Global().Get("Date").Call("now").Float() / 1000)
+}
+
+// sleepMillis waits ms milliseconds on a setTimeout timer. It is the default
+// DB sleep.
+func sleepMillis(ms int64) {
+	done := make(chan struct{})
+	var cb js.Func
+	cb = js.FuncOf(func(js.Value, []js.Value) any {
+		cb.Release()
+		close(done)
+		return nil
+	})
+	js.Global().Call("setTimeout", cb, ms)
+	<-done
+}
@@ -15,6 +15,17 @@ func main() {
 	o.SetLog(func(messages ...any) {
 		fmt.Fprintln(os.Stderr, messages...)
 	})
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if len(os.Args) != 4 || os.Args[2] != "new" {
+			log.Fatalf("usage: ormc migrate new <name>")
+		}
+		if _, err := o.MigrateNew(os.Args[3]); err != nil {
+			log.Fatalf("ormc: %v", err)
+		}
+		return
+	}
+
 	if err := o.Run(); err != nil {
 		log.Fatalf("ormc: %v", err)
 	}
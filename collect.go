@@ -0,0 +1,59 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// Generic typed reads. PT is the pointer type implementing fmt.Model and is
+// inferred from T: orm.Collect[User](qb) reads []*User.
+
+// Collect reads all rows of qb into a slice.
+func Collect[T any, PT interface {
+	*T
+	fmt.Model
+}](qb *QB) ([]*T, error) {
+	var results []*T
+	err := qb.readAll(
+		func() fmt.Model { return PT(new(T)) },
+		func(m fmt.Model) bool {
+			results = append(results, (*T)(m.(PT)))
+			return true
+		},
+	)
+	return results, err
+}
+
+// First reads the first row of qb into a new *T. qb is not modified.
+// Returns ErrNotFound when no row matches.
+func First[T any, PT interface {
+	*T
+	fmt.Model
+}](qb *QB) (*T, error) {
+	q := *qb
+	q.limit = 1
+	var first *T
+	err := q.readAll(
+		func() fmt.Model { return PT(new(T)) },
+		func(m fmt.Model) bool {
+			first = (*T)(m.(PT))
+			return false
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, ErrNotFound
+	}
+	return first, nil
+}
+
+// Each calls fn for every row of qb as it is read. Returning false from fn
+// stops the read and closes the rows.
+func Each[T any, PT interface {
+	*T
+	fmt.Model
+}](qb *QB, fn func(*T) bool) error {
+	return qb.readAll(
+		func() fmt.Model { return PT(new(T)) },
+		func(m fmt.Model) bool { return fn((*T)(m.(PT))) },
+	)
+}
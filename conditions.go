@@ -7,6 +7,7 @@ type Condition struct {
 	operator string
 	value    any
 	logic    string
+	group    []Condition
 }
 
 func (c Condition) Field() string    { return c.field }
@@ -14,6 +15,9 @@ func (c Condition) Operator() string { return c.operator }
 func (c Condition) Value() any       { return c.value }
 func (c Condition) Logic() string    { return c.logic }
 
+// Group returns the sub-conditions of a "GROUP" condition, nil otherwise.
+func (c Condition) Group() []Condition { return c.group }
+
 // Eq creates a condition for checking equality.
 func Eq(field string, value any) Condition {
 	return Condition{
@@ -99,3 +103,40 @@ func Or(c Condition) Condition {
 	c.logic = "OR"
 	return c
 }
+
+// Group creates a parenthesized condition from conds. It has operator "GROUP";
+// compilers render Group() with its own logic inside parentheses.
+func Group(conds ...Condition) Condition {
+	return Condition{
+		operator: "GROUP",
+		logic:    "AND",
+		group:    conds,
+	}
+}
+
+// andAll returns conds followed by the mandatory extra conditions, joined by
+// AND. When conds use OR logic they are grouped first, so the extra
+// conditions cannot be bypassed through operator precedence.
+func andAll(conds []Condition, extra ...Condition) []Condition {
+	if len(extra) == 0 {
+		return conds
+	}
+	out := make([]Condition, 0, len(conds)+len(extra))
+	hasOr := false
+	for _, c := range conds {
+		if c.logic == "OR" {
+			hasOr = true
+			break
+		}
+	}
+	if hasOr {
+		out = append(out, Group(conds...))
+	} else {
+		out = append(out, conds...)
+	}
+	for _, c := range extra {
+		c.logic = "AND"
+		out = append(out, c)
+	}
+	return out
+}
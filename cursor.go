@@ -0,0 +1,319 @@
+package orm
+
+import (
+	"math"
+
+	"github.com/tinywasm/fmt"
+)
+
+// Cursor is an opaque keyset position: the values of the sort columns of one
+// row. It is produced by QB.Cursor and sent to clients with String; the next
+// request passes it back through ParseCursor to QB.After or QB.Before.
+// The zero Cursor is the start of the result set. A cursor also records a
+// hash of the sort columns it was taken with, so it is refused by a query
+// sorted differently.
+type Cursor struct {
+	order  uint64
+	values []any
+}
+
+// cursor value tags
+const (
+	curNil    = 'n'
+	curInt    = 'i'
+	curUint   = 'u'
+	curFloat  = 'f'
+	curBool   = 'b'
+	curString = 's'
+	curBytes  = 'x'
+	curOrder  = 'o' // sort columns hash, first in every non-zero cursor
+)
+
+const hexDigits = "0123456789abcdef"
+
+// IsZero reports whether c holds no position.
+func (c Cursor) IsZero() bool { return len(c.values) == 0 }
+
+// String encodes c as a URL-safe hex string. Values keep their type:
+// integers, floats, bools, strings and []byte round-trip exactly; any other
+// type is encoded through its string form.
+func (c Cursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	buf := appendUint64([]byte{curOrder}, c.order)
+	for _, v := range c.values {
+		switch x := v.(type) {
+		case nil:
+			buf = append(buf, curNil)
+		case int:
+			buf = appendUint64(append(buf, curInt), uint64(x))
+		case int32:
+			buf = appendUint64(append(buf, curInt), uint64(x))
+		case int64:
+			buf = appendUint64(append(buf, curInt), uint64(x))
+		case uint:
+			buf = appendUint64(append(buf, curUint), uint64(x))
+		case uint32:
+			buf = appendUint64(append(buf, curUint), uint64(x))
+		case uint64:
+			buf = appendUint64(append(buf, curUint), x)
+		case float32:
+			buf = appendUint64(append(buf, curFloat), math.Float64bits(float64(x)))
+		case float64:
+			buf = appendUint64(append(buf, curFloat), math.Float64bits(x))
+		case bool:
+			b := byte(0)
+			if x {
+				b = 1
+			}
+			buf = append(buf, curBool, b)
+		case []byte:
+			buf = appendBytes(append(buf, curBytes), x)
+		case string:
+			buf = appendBytes(append(buf, curString), []byte(x))
+		default:
+			buf = appendBytes(append(buf, curString), []byte(fmt.Convert(x).String()))
+		}
+	}
+	out := make([]byte, len(buf)*2)
+	for i, b := range buf {
+		out[i*2] = hexDigits[b>>4]
+		out[i*2+1] = hexDigits[b&0x0f]
+	}
+	return string(out)
+}
+
+// ParseCursor decodes a string produced by Cursor.String. The empty string
+// is the zero Cursor. Malformed input returns ErrInvalidCursor.
+func ParseCursor(s string) (Cursor, error) {
+	if len(s)%2 != 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	buf := make([]byte, len(s)/2)
+	for i := range buf {
+		hi, ok1 := unhex(s[i*2])
+		lo, ok2 := unhex(s[i*2+1])
+		if !ok1 || !ok2 {
+			return Cursor{}, ErrInvalidCursor
+		}
+		buf[i] = hi<<4 | lo
+	}
+
+	var c Cursor
+	if len(buf) == 0 {
+		return c, nil
+	}
+	if len(buf) < 9 || buf[0] != curOrder {
+		return Cursor{}, ErrInvalidCursor
+	}
+	c.order = readUint64(buf[1:])
+	buf = buf[9:]
+	for len(buf) > 0 {
+		tag := buf[0]
+		buf = buf[1:]
+		switch tag {
+		case curNil:
+			c.values = append(c.values, nil)
+		case curInt, curUint, curFloat:
+			if len(buf) < 8 {
+				return Cursor{}, ErrInvalidCursor
+			}
+			n := readUint64(buf)
+			buf = buf[8:]
+			switch tag {
+			case curInt:
+				c.values = append(c.values, int64(n))
+			case curUint:
+				c.values = append(c.values, n)
+			default:
+				c.values = append(c.values, math.Float64frombits(n))
+			}
+		case curBool:
+			if len(buf) < 1 {
+				return Cursor{}, ErrInvalidCursor
+			}
+			c.values = append(c.values, buf[0] == 1)
+			buf = buf[1:]
+		case curString, curBytes:
+			if len(buf) < 4 {
+				return Cursor{}, ErrInvalidCursor
+			}
+			n := int(uint32(buf[0])<<24 | uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3]))
+			buf = buf[4:]
+			if n > len(buf) {
+				return Cursor{}, ErrInvalidCursor
+			}
+			if tag == curString {
+				c.values = append(c.values, string(buf[:n]))
+			} else {
+				c.values = append(c.values, append([]byte(nil), buf[:n]...))
+			}
+			buf = buf[n:]
+		default:
+			return Cursor{}, ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
+
+// orderHash identifies the sort columns and directions of a keyset query.
+func orderHash(order []Order) uint64 {
+	h := uint64(fnvOffset)
+	for _, o := range order {
+		h = fnv1a(h, o.column+" "+o.dir+",")
+	}
+	return h
+}
+
+// FNV-1a 64-bit parameters
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// fnv1a folds s into the FNV-1a hash h.
+func fnv1a(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return append(buf, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func readUint64(b []byte) uint64 {
+	return uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32 |
+		uint64(b[4])<<24 | uint64(b[5])<<16 | uint64(b[6])<<8 | uint64(b[7])
+}
+
+func appendBytes(buf, b []byte) []byte {
+	n := len(b)
+	buf = append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	return append(buf, b...)
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// keyset modes of a QB
+const (
+	keysetNone uint8 = iota
+	keysetAfter
+	keysetBefore
+)
+
+// After pages forward: only rows sorted after c are returned. The PK is added
+// as the final sort key so the order is total. Pass the zero Cursor for the
+// first page, so it is sorted the same way as the following ones.
+func (qb *QB) After(c Cursor) *QB {
+	qb.keyset, qb.cursor = keysetAfter, c
+	return qb
+}
+
+// Before pages backward: only rows sorted before c are returned. The query
+// runs in reverse order so Limit keeps the rows closest to c; they arrive
+// nearest-first, i.e. reversed relative to OrderBy.
+func (qb *QB) Before(c Cursor) *QB {
+	qb.keyset, qb.cursor = keysetBefore, c
+	return qb
+}
+
+// Cursor returns the position of m, a row read by this query, for use with
+// After or Before on the next request.
+func (qb *QB) Cursor(m fmt.Model) Cursor {
+	schema := m.Schema()
+	values := fmt.ReadValues(schema, m.Pointers())
+	keys := qb.keysetOrder()
+	c := Cursor{order: orderHash(keys), values: make([]any, len(keys))}
+	for i, o := range keys {
+		for j, f := range schema {
+			if f.Name == o.column && j < len(values) {
+				c.values[i] = values[j]
+				break
+			}
+		}
+	}
+	return c
+}
+
+// keysetOrder returns the sort columns of a keyset query: OrderBy plus the PK
+// of the model when not already sorted on, in the direction of the last
+// OrderBy (ascending by default).
+func (qb *QB) keysetOrder() []Order {
+	order := qb.orderBy
+	pk := ""
+	for _, f := range qb.model.Schema() {
+		if f.IsPK() {
+			pk = f.Name
+			break
+		}
+	}
+	if pk == "" {
+		return order
+	}
+	dir := "ASC"
+	for _, o := range order {
+		if o.column == pk {
+			return order
+		}
+		dir = o.dir
+	}
+	return append(order[:len(order):len(order)], Order{column: pk, dir: dir})
+}
+
+// applyKeyset returns the order and extra conditions of a keyset query.
+// For sort columns c1..cn the condition is
+// (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ..., with < for descending columns
+// and all comparisons flipped for Before.
+func (qb *QB) applyKeyset() ([]Order, []Condition, error) {
+	order := qb.keysetOrder()
+	hash := orderHash(order)
+	if qb.keyset == keysetBefore {
+		rev := make([]Order, len(order))
+		for i, o := range order {
+			rev[i] = Order{column: o.column, dir: "DESC"}
+			if o.dir == "DESC" {
+				rev[i].dir = "ASC"
+			}
+		}
+		order = rev
+	}
+	if qb.cursor.IsZero() {
+		return order, nil, nil
+	}
+	if qb.cursor.order != hash || len(qb.cursor.values) != len(order) {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	var branches []Condition
+	for i, o := range order {
+		var conds []Condition
+		for j := 0; j < i; j++ {
+			conds = append(conds, Eq(order[j].column, qb.cursor.values[j]))
+		}
+		if o.dir == "DESC" {
+			conds = append(conds, Lt(o.column, qb.cursor.values[i]))
+		} else {
+			conds = append(conds, Gt(o.column, qb.cursor.values[i]))
+		}
+		branch := Group(conds...)
+		if i > 0 {
+			branch = Or(branch)
+		}
+		branches = append(branches, branch)
+	}
+	return order, []Condition{Group(branches...)}, nil
+}
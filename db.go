@@ -5,76 +5,276 @@ import "github.com/tinywasm/fmt"
 // DB represents a database connection.
 // Consumers instantiate it via New().
 type DB struct {
-	exec     Executor
-	compiler Compiler
+	exec        Executor
+	compiler    Compiler
+	noValidate  bool
+	now         func() int64 // unix seconds, for timestamps and soft deletes
+	sleep       func(ms int64)
+	scopes      []QueryScope
+	writeScopes []func(m fmt.Model) // fill Create/Update models, see WithTenant
+	txDepth     int                 // 0 outside Tx; nested Tx calls use savepoints
+	replicas    *replicaSet
+}
+
+// Option configures a DB created by New.
+type Option func(*DB)
+
+// WithoutValidation stops Create, Update and Delete from calling the model's
+// Validate method.
+func WithoutValidation() Option {
+	return func(db *DB) { db.noValidate = true }
+}
+
+// WithClock sets the function returning the current unix time in seconds,
+// used for db:"created"/db:"updated" timestamps and soft deletes. Tests pin it
+// to a fixed value. Defaults to the system clock.
+func WithClock(now func() int64) Option {
+	return func(db *DB) { db.now = now }
+}
+
+// WithSleep sets the function that waits ms milliseconds between TxRetry
+// attempts. Tests replace it to run without delays. Defaults to a system timer.
+func WithSleep(sleep func(ms int64)) Option {
+	return func(db *DB) { db.sleep = sleep }
 }
 
 // New creates a new DB instance.
-func New(exec Executor, compiler Compiler) *DB {
-	return &DB{
+func New(exec Executor, compiler Compiler, opts ...Option) *DB {
+	db := &DB{
 		exec:     exec,
 		compiler: compiler,
+		now:      unixNow,
+		sleep:    sleepMillis,
 	}
+	for _, opt := range opts {
+		opt(db)
+	}
+	return db
 }
 
 // Create inserts a new model into the database.
+// BeforeCreate/AfterCreate hooks run around the insert; the model is
+// timestamped and validated with Validate('c') after BeforeCreate.
 func (db *DB) Create(m fmt.Model) error {
+	if err := writable(m); err != nil {
+		return err
+	}
+	db.applyWriteScopes(m)
+	if err := db.hook(hookBeforeCreate, m); err != nil {
+		return err
+	}
+	db.touch(m, true)
+	if err := db.validate('c', m); err != nil {
+		return err
+	}
+	if err := db.insert(m); err != nil {
+		return err
+	}
+	return db.hook(hookAfterCreate, m)
+}
+
+func (db *DB) insert(m fmt.Model) error {
 	if err := validateQuery(ActionCreate, m); err != nil {
 		return err
 	}
 	schema := m.Schema()
 	ptrs := m.Pointers()
 	allValues := fmt.ReadValues(schema, ptrs)
+	var ext []FieldExt
+	if e, ok := m.(FieldExtender); ok {
+		ext = e.SchemaExt()
+	}
 	var columns []string
 	var values []any
+	var returning []string
+	var idPtr any
 	for i, f := range schema {
-		// Skip autoincrement PK fields with zero value — let the DB assign them.
-		if f.IsPK() && f.IsAutoInc() {
-			if v, ok := allValues[i].(int); ok && v == 0 {
-				continue
-			}
+		// Skip autoincrement PK fields with zero value — let the DB assign them
+		// and write the generated ID back through the PK pointer.
+		if f.IsPK() && f.IsAutoInc() && isZeroValue(allValues[i]) {
+			returning = append(returning, f.Name)
+			idPtr = ptrs[i]
+			continue
+		}
+		// Skip zero-valued fields with a DB default — let the DB fill them.
+		if fieldExtAt(ext, i).Default != "" && isZeroValue(allValues[i]) {
+			continue
 		}
 		columns = append(columns, f.Name)
 		values = append(values, allValues[i])
 	}
 	q := Query{
-		Action:  ActionCreate,
-		Table:   m.ModelName(),
-		Columns: columns,
-		Values:  values,
+		Action:    ActionCreate,
+		Table:     m.ModelName(),
+		Columns:   columns,
+		Values:    values,
+		Returning: returning,
+	}
+	plan, exec, err := db.compile(db.exec, q, m)
+	if err != nil {
+		return err
 	}
-	plan, err := db.compiler.Compile(q, m)
+	if idPtr == nil {
+		return exec.Exec(plan.Query, plan.Args...)
+	}
+	return insertReturningID(exec, plan, idPtr)
+}
+
+// insertReturningID runs an insert plan and stores the generated ID through
+// idPtr, either by scanning the RETURNING row or from the executor's
+// LastInsertID. Executors supporting neither leave the PK untouched.
+func insertReturningID(exec Executor, plan Plan, idPtr any) error {
+	if plan.Returning {
+		return exec.QueryRow(plan.Query, plan.Args...).Scan(idPtr)
+	}
+	res, err := execResult(exec, plan)
 	if err != nil {
 		return err
 	}
-	return db.exec.Exec(plan.Query, plan.Args...)
+	if res.LastInsertID >= 0 {
+		setIntPtr(idPtr, res.LastInsertID)
+	}
+	return nil
+}
+
+// touch sets the db:"updated" field of m, and on create a zero db:"created"
+// field, to db.now().
+func (db *DB) touch(m fmt.Model, creating bool) {
+	e, ok := m.(FieldExtender)
+	if !ok {
+		return
+	}
+	ptrs := m.Pointers()
+	var now int64
+	for i, f := range e.SchemaExt() {
+		if i >= len(ptrs) || !(f.Updated || f.Created && creating) {
+			continue
+		}
+		if f.Created {
+			if p, ok := ptrs[i].(*int64); !ok || *p != 0 {
+				continue
+			}
+		}
+		if now == 0 {
+			now = db.now()
+		}
+		setIntPtr(ptrs[i], now)
+	}
+}
+
+// execResult runs plan through ResultExecutor when exec implements it.
+// Otherwise the plan is run with plain Exec and unknownResult is returned.
+func execResult(exec Executor, plan Plan) (Result, error) {
+	re, ok := exec.(ResultExecutor)
+	if !ok {
+		return unknownResult, exec.Exec(plan.Query, plan.Args...)
+	}
+	return re.ExecResult(plan.Query, plan.Args...)
 }
 
 // Update modifies an existing row. At least one Condition is required.
 // Providing zero conditions is a compile-time error — there is no variadic
 // fallback — preventing accidental full-table UPDATE statements.
+//
+// For models with a db:"version" field the update only matches rows still at
+// the model's version, increments it and returns ErrStaleObject when no row
+// matched. Detecting the miss requires a ResultExecutor.
+//
+// BeforeUpdate/AfterUpdate hooks run around the update; the model is
+// timestamped and validated with Validate('u') after BeforeUpdate. The
+// db:"created" and db:"deleted_at" columns are left out of the update, and
+// soft-deleted rows are not matched.
 func (db *DB) Update(m fmt.Model, cond Condition, rest ...Condition) error {
+	_, err := db.UpdateN(m, cond, rest...)
+	return err
+}
+
+// UpdateN is Update that also reports the write Result. Executors that do not
+// implement ResultExecutor report -1 for both counters.
+func (db *DB) UpdateN(m fmt.Model, cond Condition, rest ...Condition) (Result, error) {
+	if err := writable(m); err != nil {
+		return Result{}, err
+	}
+	db.applyWriteScopes(m)
+	if err := db.hook(hookBeforeUpdate, m); err != nil {
+		return Result{}, err
+	}
+	db.touch(m, false)
+	if err := db.validate('u', m); err != nil {
+		return Result{}, err
+	}
+	res, err := db.update(m, append([]Condition{cond}, rest...))
+	if err != nil {
+		return res, err
+	}
+	return res, db.hook(hookAfterUpdate, m)
+}
+
+func (db *DB) update(m fmt.Model, conds []Condition) (Result, error) {
 	if err := validateQuery(ActionUpdate, m); err != nil {
-		return err
+		return Result{}, err
 	}
-	conds := append([]Condition{cond}, rest...)
 	schema := m.Schema()
+	ptrs := m.Pointers()
 	columns := make([]string, len(schema))
 	for i, f := range schema {
 		columns[i] = f.Name
 	}
+	values := fmt.ReadValues(schema, ptrs)
+
+	// Optimistic locking: only update the row still holding the version that
+	// was read, and bump it.
+	ver := versionIndex(m)
+	var next int64
+	if ver >= 0 {
+		cur, _ := intValue(values[ver])
+		next = cur + 1
+		conds = andAll(conds, Eq(schema[ver].Name, values[ver]))
+		values[ver] = next
+	}
+
+	// Soft-deleted rows are only brought back by Restore: the marker is not
+	// written and only live rows match.
+	sd := softDeleteIndex(m)
+	if sd >= 0 {
+		conds = andAll(conds, Eq(schema[sd].Name, int64(0)))
+	}
+
+	// The creation timestamp and the soft-delete marker are never rewritten.
+	ci := createdIndex(m)
+	if ci >= 0 || sd >= 0 {
+		cols, vals := columns[:0:0], values[:0:0]
+		for i := range columns {
+			if i != ci && i != sd {
+				cols = append(cols, columns[i])
+				vals = append(vals, values[i])
+			}
+		}
+		columns, values = cols, vals
+	}
+
 	q := Query{
 		Action:     ActionUpdate,
 		Table:      m.ModelName(),
 		Columns:    columns,
-		Values:     fmt.ReadValues(schema, m.Pointers()),
+		Values:     values,
 		Conditions: conds,
 	}
-	plan, err := db.compiler.Compile(q, m)
+	plan, exec, err := db.compile(db.exec, q, m)
 	if err != nil {
-		return err
+		return Result{}, err
+	}
+	res, err := execResult(exec, plan)
+	if err != nil {
+		return res, err
 	}
-	return db.exec.Exec(plan.Query, plan.Args...)
+	if ver >= 0 {
+		if res.RowsAffected == 0 {
+			return res, ErrStaleObject
+		}
+		setIntPtr(ptrs[ver], next)
+	}
+	return res, nil
 }
 
 // emptyModel is a private zero-value type used only for CreateDatabase.
@@ -93,11 +293,11 @@ func (db *DB) CreateTable(m fmt.Model) error {
 		Action: ActionCreateTable,
 		Table:  m.ModelName(),
 	}
-	plan, err := db.compiler.Compile(q, m)
+	plan, exec, err := db.compile(db.exec, q, m)
 	if err != nil {
 		return err
 	}
-	return db.exec.Exec(plan.Query, plan.Args...)
+	return exec.Exec(plan.Query, plan.Args...)
 }
 
 // DropTable drops the table for the given model.
@@ -109,11 +309,11 @@ func (db *DB) DropTable(m fmt.Model) error {
 		Action: ActionDropTable,
 		Table:  m.ModelName(),
 	}
-	plan, err := db.compiler.Compile(q, m)
+	plan, exec, err := db.compile(db.exec, q, m)
 	if err != nil {
 		return err
 	}
-	return db.exec.Exec(plan.Query, plan.Args...)
+	return exec.Exec(plan.Query, plan.Args...)
 }
 
 // CreateDatabase creates a new database.
@@ -126,31 +326,66 @@ func (db *DB) CreateDatabase(name string) error {
 		Action:   ActionCreateDatabase,
 		Database: name,
 	}
-	plan, err := db.compiler.Compile(q, m)
+	plan, exec, err := db.compile(db.exec, q, m)
 	if err != nil {
 		return err
 	}
-	return db.exec.Exec(plan.Query, plan.Args...)
+	return exec.Exec(plan.Query, plan.Args...)
 }
 
 // Delete deletes a model from the database.
 // At least one Condition is required. Providing zero conditions is a compile-time
 // error, preventing accidental full-table DELETE statements.
+// For models with a db:"deleted_at" field the row is soft-deleted instead;
+// see ForceDelete.
+// BeforeDelete/AfterDelete hooks run around the delete; the model is
+// validated with Validate('d') after BeforeDelete.
 func (db *DB) Delete(m fmt.Model, cond Condition, rest ...Condition) error {
-	if err := validateQuery(ActionDelete, m); err != nil {
-		return err
+	_, err := db.DeleteN(m, cond, rest...)
+	return err
+}
+
+// DeleteN is Delete that also reports the write Result, e.g. RowsAffected == 0
+// for a missing record. Executors that do not implement ResultExecutor report
+// -1 for both counters.
+func (db *DB) DeleteN(m fmt.Model, cond Condition, rest ...Condition) (Result, error) {
+	if err := writable(m); err != nil {
+		return Result{}, err
+	}
+	if err := db.hook(hookBeforeDelete, m); err != nil {
+		return Result{}, err
+	}
+	if err := db.validate('d', m); err != nil {
+		return Result{}, err
 	}
 	conds := append([]Condition{cond}, rest...)
+	var res Result
+	var err error
+	if i := softDeleteIndex(m); i >= 0 {
+		res, err = db.softDelete(m, i, conds)
+	} else {
+		res, err = db.delete(m, conds)
+	}
+	if err != nil {
+		return res, err
+	}
+	return res, db.hook(hookAfterDelete, m)
+}
+
+func (db *DB) delete(m fmt.Model, conds []Condition) (Result, error) {
+	if err := validateQuery(ActionDelete, m); err != nil {
+		return Result{}, err
+	}
 	q := Query{
 		Action:     ActionDelete,
 		Table:      m.ModelName(),
 		Conditions: conds,
 	}
-	plan, err := db.compiler.Compile(q, m)
+	plan, exec, err := db.compile(db.exec, q, m)
 	if err != nil {
-		return err
+		return Result{}, err
 	}
-	return db.exec.Exec(plan.Query, plan.Args...)
+	return execResult(exec, plan)
 }
 
 // Query creates a new QB instance.
@@ -161,12 +396,59 @@ func (db *DB) Query(m fmt.Model) *QB {
 	}
 }
 
-// Close closes the underlying executor if it supports it.
+// Close closes the underlying executor if it supports it, then the replicas.
+// The first error is returned.
 func (db *DB) Close() error {
-	return db.exec.Close()
+	err := db.exec.Close()
+	if db.replicas != nil {
+		for _, rep := range db.replicas.replicas {
+			if rerr := rep.Exec.Close(); err == nil {
+				err = rerr
+			}
+		}
+	}
+	return err
 }
 
 // RawExecutor returns the underlying executor instance.
+// On a scoped DB (see WithScope) it returns an executor whose queries fail
+// with ErrScoped; call Unscoped().RawExecutor() to run raw SQL deliberately.
 func (db *DB) RawExecutor() Executor {
+	if len(db.scopes) > 0 {
+		return refusingExecutor{exec: db.exec}
+	}
 	return db.exec
 }
+
+// AddColumn adds column to the table of m. The column definition is read from
+// the matching entry in m.Schema().
+func (db *DB) AddColumn(m fmt.Model, column string) error {
+	return db.alterTable(ActionAddColumn, m, column)
+}
+
+// DropColumn removes column from the table of m.
+func (db *DB) DropColumn(m fmt.Model, column string) error {
+	return db.alterTable(ActionDropColumn, m, column)
+}
+
+// AlterColumn changes the definition of column to match its entry in m.Schema().
+// Engines that cannot alter columns in place return an error from their Compiler.
+func (db *DB) AlterColumn(m fmt.Model, column string) error {
+	return db.alterTable(ActionAlterColumn, m, column)
+}
+
+func (db *DB) alterTable(action Action, m fmt.Model, column string) error {
+	if err := validateQuery(action, m); err != nil {
+		return err
+	}
+	q := Query{
+		Action:  action,
+		Table:   m.ModelName(),
+		Columns: []string{column},
+	}
+	plan, exec, err := db.compile(db.exec, q, m)
+	if err != nil {
+		return err
+	}
+	return exec.Exec(plan.Query, plan.Args...)
+}
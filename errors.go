@@ -13,3 +13,28 @@ var ErrEmptyTable = fmt.Err("name", "table", "empty")
 
 // ErrNoTxSupport is returned by DB.Tx() when the executor does not implement TxExecutor.
 var ErrNoTxSupport = fmt.Err("transaction", "not", "supported")
+
+// ErrStaleObject is returned by DB.Update() when a model with a db:"version"
+// field was changed by someone else since it was read.
+var ErrStaleObject = fmt.Err("record", "modified", "concurrently")
+
+// ErrScoped is returned by raw queries on a scoped DB, which would bypass its
+// scopes. Use DB.Unscoped() to run them.
+var ErrScoped = fmt.Err("query", "bypasses", "scope")
+
+// ErrReadOnlyView is returned by Create, Update, Delete, Restore and
+// ForceDelete for a ViewModel.
+var ErrReadOnlyView = fmt.Err("view", "read", "only")
+
+// ErrInvalidCursor is returned for a malformed keyset cursor, or one taken
+// with other sort columns or directions than the query's.
+var ErrInvalidCursor = fmt.Err("cursor", "invalid")
+
+// ErrStop is returned by a QB.ReadAllE callback to end the read early, closing
+// the rows; ReadAllE then returns nil.
+var ErrStop = fmt.Err("read", "stopped")
+
+// ErrUnroutable is returned by ShardedExecutor for a query it cannot send to
+// its shards: a write without a shard key, raw SQL, a read spanning shards
+// with OrderBy, GroupBy or Offset, or a key mapped outside the shards.
+var ErrUnroutable = fmt.Err("shard", "unroutable")
@@ -2,7 +2,11 @@ package orm
 
 // Plan describes how the Executor should run the operation.
 type Plan struct {
-	Mode   Action
-	Query  string
-	Args   []any
+	Mode  Action
+	Query string
+	Args  []any
+	// Returning reports that Query yields the Query.Returning columns as a
+	// single row (e.g. INSERT ... RETURNING id). The DB scans that row
+	// instead of calling Exec.
+	Returning bool
 }
@@ -9,6 +9,23 @@ type Executor interface {
 	Close() error
 }
 
+// Result reports the outcome of a write operation.
+// A value of -1 means the executor cannot report it (see ResultExecutor).
+type Result struct {
+	RowsAffected int64
+	LastInsertID int64
+}
+
+// unknownResult is reported for writes run on executors without ExecResult.
+var unknownResult = Result{RowsAffected: -1, LastInsertID: -1}
+
+// ResultExecutor is an optional Executor extension for engines that can
+// report write results (sql.Result in database/sql terms).
+type ResultExecutor interface {
+	Executor
+	ExecResult(query string, args ...any) (Result, error)
+}
+
 // Scanner represents a single row scanner.
 type Scanner interface {
 	Scan(dest ...any) error
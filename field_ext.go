@@ -2,10 +2,75 @@ package orm
 
 import "github.com/tinywasm/fmt"
 
-// FieldExt extends fmt.Field with database-specific metadata (foreign keys).
-// Used internally by adapters that support FK constraints.
+// FieldExt extends fmt.Field with database-specific metadata (foreign keys,
+// defaults) and the column roles the DB manages itself (version counters,
+// soft-delete markers, timestamps).
+// Used internally by adapters that support FK constraints and column defaults.
 type FieldExt struct {
 	fmt.Field
-	Ref       string // FK: target table name. Empty = no FK.
-	RefColumn string // FK: target column. Empty = auto-detect PK of Ref table.
+	Ref        string // FK: target table name. Empty = no FK.
+	RefColumn  string // FK: target column. Empty = auto-detect PK of Ref table.
+	Default    string // DB default as a SQL literal, e.g. "0" or "'pending'". Empty = no default.
+	Version    bool   // optimistic-lock counter (db:"version"); integer fields only.
+	SoftDelete bool   // soft-delete marker (db:"deleted_at"); int64 unix time, 0 = live.
+	Created    bool   // set on Create (db:"created"); int64 unix time.
+	Updated    bool   // set on Create and Update (db:"updated"); int64 unix time.
+}
+
+// FieldExtender is implemented by models that carry FieldExt metadata.
+// Implementations are generated by ormc; SchemaExt()[i] describes Schema()[i].
+type FieldExtender interface {
+	SchemaExt() []FieldExt
+}
+
+// SchemaExt returns the extended schema of m. Models that do not implement
+// FieldExtender get their plain Schema() wrapped with empty metadata.
+func SchemaExt(m fmt.Fielder) []FieldExt {
+	if e, ok := m.(FieldExtender); ok {
+		return e.SchemaExt()
+	}
+	schema := m.Schema()
+	ext := make([]FieldExt, len(schema))
+	for i, f := range schema {
+		ext[i].Field = f
+	}
+	return ext
+}
+
+// fieldExtAt returns ext[i], or an empty FieldExt when ext is shorter.
+func fieldExtAt(ext []FieldExt, i int) FieldExt {
+	if i < len(ext) {
+		return ext[i]
+	}
+	return FieldExt{}
+}
+
+// fieldIndex returns the index of the first field of m whose FieldExt
+// matches is, or -1. Models without FieldExt metadata never match.
+func fieldIndex(m fmt.Fielder, is func(FieldExt) bool) int {
+	e, ok := m.(FieldExtender)
+	if !ok {
+		return -1
+	}
+	for i, f := range e.SchemaExt() {
+		if is(f) {
+			return i
+		}
+	}
+	return -1
+}
+
+// versionIndex returns the index of the db:"version" field of m, or -1.
+func versionIndex(m fmt.Fielder) int {
+	return fieldIndex(m, func(f FieldExt) bool { return f.Version })
+}
+
+// softDeleteIndex returns the index of the db:"deleted_at" field of m, or -1.
+func softDeleteIndex(m fmt.Fielder) int {
+	return fieldIndex(m, func(f FieldExt) bool { return f.SoftDelete })
+}
+
+// createdIndex returns the index of the db:"created" field of m, or -1.
+func createdIndex(m fmt.Fielder) int {
+	return fieldIndex(m, func(f FieldExt) bool { return f.Created })
 }
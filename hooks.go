@@ -0,0 +1,94 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// Lifecycle hooks are optional interfaces detected on the model passed to DB
+// and QB operations. Each receives the *DB running the operation — the
+// transactional one inside DB.Tx — so hooks can issue further queries.
+//
+// A Before* error aborts the operation before anything reaches the database.
+// An After* error is returned to the caller after the write has run; inside
+// DB.Tx it rolls the transaction back.
+
+// BeforeCreateHook runs before DB.Create reads the model values.
+type BeforeCreateHook interface {
+	BeforeCreate(db *DB) error
+}
+
+// AfterCreateHook runs after a successful DB.Create, with generated IDs set.
+type AfterCreateHook interface {
+	AfterCreate(db *DB) error
+}
+
+// BeforeUpdateHook runs before DB.Update/UpdateN reads the model values.
+type BeforeUpdateHook interface {
+	BeforeUpdate(db *DB) error
+}
+
+// AfterUpdateHook runs after a successful DB.Update/UpdateN.
+type AfterUpdateHook interface {
+	AfterUpdate(db *DB) error
+}
+
+// BeforeDeleteHook runs before DB.Delete/DeleteN.
+type BeforeDeleteHook interface {
+	BeforeDelete(db *DB) error
+}
+
+// AfterDeleteHook runs after a successful DB.Delete/DeleteN.
+type AfterDeleteHook interface {
+	AfterDelete(db *DB) error
+}
+
+// AfterReadHook runs on every model filled by QB.ReadOne/ReadAll, before it
+// is handed to the caller.
+type AfterReadHook interface {
+	AfterRead(db *DB) error
+}
+
+type hookPoint uint8
+
+const (
+	hookBeforeCreate hookPoint = iota
+	hookAfterCreate
+	hookBeforeUpdate
+	hookAfterUpdate
+	hookBeforeDelete
+	hookAfterDelete
+	hookAfterRead
+)
+
+// hook calls the hook for point p when m implements it.
+func (db *DB) hook(p hookPoint, m fmt.Model) error {
+	switch p {
+	case hookBeforeCreate:
+		if h, ok := m.(BeforeCreateHook); ok {
+			return h.BeforeCreate(db)
+		}
+	case hookAfterCreate:
+		if h, ok := m.(AfterCreateHook); ok {
+			return h.AfterCreate(db)
+		}
+	case hookBeforeUpdate:
+		if h, ok := m.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate(db)
+		}
+	case hookAfterUpdate:
+		if h, ok := m.(AfterUpdateHook); ok {
+			return h.AfterUpdate(db)
+		}
+	case hookBeforeDelete:
+		if h, ok := m.(BeforeDeleteHook); ok {
+			return h.BeforeDelete(db)
+		}
+	case hookAfterDelete:
+		if h, ok := m.(AfterDeleteHook); ok {
+			return h.AfterDelete(db)
+		}
+	case hookAfterRead:
+		if h, ok := m.(AfterReadHook); ok {
+			return h.AfterRead(db)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// Index describes a secondary index declared via db:"index", db:"index=name"
+// or db:"unique=name" tags. Columns keep struct field order.
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// Indexer is implemented by models that declare secondary indexes.
+// Implementations are generated by ormc.
+type Indexer interface {
+	Indexes() []Index
+}
+
+// CreateIndex creates idx on the table of m.
+func (db *DB) CreateIndex(m fmt.Model, idx Index) error {
+	return db.indexDDL(ActionCreateIndex, m, idx)
+}
+
+// DropIndex drops the index called name from the table of m.
+func (db *DB) DropIndex(m fmt.Model, name string) error {
+	return db.indexDDL(ActionDropIndex, m, Index{Name: name})
+}
+
+func (db *DB) indexDDL(action Action, m fmt.Model, idx Index) error {
+	if err := validateQuery(action, m); err != nil {
+		return err
+	}
+	q := Query{
+		Action: action,
+		Table:  m.ModelName(),
+		Index:  idx,
+	}
+	plan, exec, err := db.compile(db.exec, q, m)
+	if err != nil {
+		return err
+	}
+	return exec.Exec(plan.Query, plan.Args...)
+}
@@ -9,6 +9,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/tinywasm/fmt"
 )
@@ -26,6 +28,13 @@ type FieldInfo struct {
 	IsPK       bool
 	GoType     string
 	OmitEmpty  bool
+	Indexes    []string // names of db:"index"/db:"index=name" indexes this column belongs to
+	Uniques    []string // names of db:"unique=name" composite unique constraints
+	Default    string   // db:"default=..." SQL literal, validated against GoType
+	Version    bool     // db:"version" optimistic-lock counter; integer GoType only
+	SoftDelete bool     // db:"deleted_at" soft-delete marker; int64 GoType only
+	Created    bool     // db:"created" timestamp set on Create; int64 GoType only
+	Updated    bool     // db:"updated" timestamp set on Create/Update; int64 GoType only
 	// Permitted config — populated from validate:"..." tag
 	Letters           bool
 	Tilde             bool
@@ -52,8 +61,13 @@ type StructInfo struct {
 	ModelNameDeclared bool
 	IsForm            bool
 	FormOnly          bool
+	Result            bool   // ormc:result directive: read-only row with Schema and Pointers only
+	View              bool   // ormc:view directive: read-only model backed by a view
+	ViewQuery         string // SELECT body of a view, from as="..." or <name>.sql
 	SourceFile        string
 	SliceFields       []SliceFieldInfo // populated by ParseStruct; used by ResolveRelations
+	Indexes           []Index          // populated by ParseStruct from index/unique= tags
+	Scopes            []string         // names X of func (T) ScopeX(*orm.QB) *orm.QB methods
 	Relations         []RelationInfo   // populated by ResolveRelations; used by GenerateForFile
 }
 
@@ -68,16 +82,7 @@ func detectModelName(node *ast.File, structName string) string {
 		if funcDecl.Name.Name != "ModelName" {
 			continue
 		}
-		recv := funcDecl.Recv.List[0].Type
-		recvName := ""
-		if ident, ok := recv.(*ast.Ident); ok {
-			recvName = ident.Name
-		} else if star, ok := recv.(*ast.StarExpr); ok {
-			if ident, ok := star.X.(*ast.Ident); ok {
-				recvName = ident.Name
-			}
-		}
-		if recvName != structName {
+		if recvTypeName(funcDecl) != structName {
 			continue
 		}
 		if funcDecl.Body != nil && len(funcDecl.Body.List) == 1 {
@@ -91,6 +96,57 @@ func detectModelName(node *ast.File, structName string) string {
 	return ""
 }
 
+// detectScopes scans the AST for named query scopes on structName:
+// func (X) ScopeName(qb *orm.QB) *orm.QB. Returns the names without the
+// "Scope" prefix, in declaration order.
+func detectScopes(node *ast.File, structName string) []string {
+	var scopes []string
+	for _, decl := range node.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+			continue
+		}
+		name := funcDecl.Name.Name
+		if !fmt.HasPrefix(name, "Scope") || len(name) == len("Scope") || recvTypeName(funcDecl) != structName {
+			continue
+		}
+		params, results := funcDecl.Type.Params.List, funcDecl.Type.Results
+		if len(params) != 1 || len(params[0].Names) > 1 || results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+			continue
+		}
+		if isQBPointer(params[0].Type) && isQBPointer(results.List[0].Type) {
+			scopes = append(scopes, fmt.Convert(name).TrimPrefix("Scope").String())
+		}
+	}
+	return scopes
+}
+
+// recvTypeName returns the receiver type name of a method, without the pointer.
+func recvTypeName(funcDecl *ast.FuncDecl) string {
+	recv := funcDecl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isQBPointer reports whether expr is the type *orm.QB.
+func isQBPointer(expr ast.Expr) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "orm" && sel.Sel.Name == "QB"
+}
+
 // ParseStruct parses a single struct from a Go file and returns its metadata.
 func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error) {
 	if structName == "" {
@@ -111,6 +167,8 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 	var structFound bool
 	var isForm bool
 	var formOnly bool
+	var result bool
+	var viewDirective string
 
 	ast.Inspect(node, func(n ast.Node) bool {
 		if genDecl, ok := n.(*ast.GenDecl); ok {
@@ -122,7 +180,13 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 							structFound = true
 							if genDecl.Doc != nil {
 								for _, comment := range genDecl.Doc.List {
-									if fmt.Contains(comment.Text, "ormc:formonly") {
+									if fmt.Contains(comment.Text, "ormc:result") {
+										result = true
+										break
+									} else if fmt.Contains(comment.Text, "ormc:view") {
+										viewDirective = comment.Text
+										break
+									} else if fmt.Contains(comment.Text, "ormc:formonly") {
 										isForm = true
 										formOnly = true
 										break
@@ -147,9 +211,28 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 
 	modelName := detectModelName(node, structName)
 	declared := modelName != ""
+
+	var viewName, viewQuery string
+	if viewDirective != "" {
+		viewName, viewQuery, err = parseViewDirective(viewDirective)
+		if err != nil {
+			return StructInfo{}, fmt.Err(err, "for view", structName)
+		}
+	}
 	if !declared {
+		modelName = viewName
+	}
+	if modelName == "" {
 		modelName = fmt.Convert(structName).SnakeLow().String()
 	}
+	if viewDirective != "" && viewQuery == "" {
+		sqlFile := filepath.Join(filepath.Dir(goFile), modelName+".sql")
+		data, err := os.ReadFile(sqlFile)
+		if err != nil {
+			return StructInfo{}, fmt.Err("view", structName, "needs as=\"SELECT ...\" or", sqlFile)
+		}
+		viewQuery = strings.TrimSuffix(strings.TrimSpace(string(data)), ";")
+	}
 
 	info := StructInfo{
 		Name:              structName,
@@ -158,9 +241,19 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 		ModelNameDeclared: declared,
 		IsForm:            isForm,
 		FormOnly:          formOnly,
+		Result:            result,
+		View:              viewDirective != "",
+		ViewQuery:         viewQuery,
+	}
+	if !formOnly && !result {
+		info.Scopes = detectScopes(node, structName)
 	}
 
 	pkFound := false
+	versionFound := false
+	softDeleteFound := false
+	createdFound := false
+	updatedFound := false
 	for _, field := range targetStruct.Fields.List {
 		if len(field.Names) == 0 {
 			continue // Anonymous field, skip for now
@@ -262,6 +355,9 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 
 		var pk, unique, notNull, autoInc bool
 		var ref, refCol string
+		var indexes, uniques []string
+		var def string
+		var version, softDelete, created, updated bool
 
 		fieldIsPK := false
 		if (isID || isPK) && !pkFound {
@@ -289,6 +385,52 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 						return StructInfo{}, fmt.Err("autoincrement not allowed on FieldText")
 					}
 					autoInc = true
+				case p == "index":
+					indexes = append(indexes, "idx_"+modelName+"_"+colName)
+				case fmt.HasPrefix(p, "index="):
+					indexes = append(indexes, fmt.Convert(p).TrimPrefix("index=").String())
+				case fmt.HasPrefix(p, "unique="):
+					uniques = append(uniques, fmt.Convert(p).TrimPrefix("unique=").String())
+				case fmt.HasPrefix(p, "default="):
+					def = fmt.Convert(p).TrimPrefix("default=").String()
+					if err := validateDefault(def, typeStr); err != nil {
+						return StructInfo{}, fmt.Err(err, "for field", structName+"."+fieldName)
+					}
+				case p == "version":
+					if !isIntegerGoType(typeStr) {
+						return StructInfo{}, fmt.Err("version field must be an integer:", structName+"."+fieldName)
+					}
+					if versionFound {
+						return StructInfo{}, fmt.Err("multiple version fields in", structName)
+					}
+					versionFound = true
+					version = true
+				case p == "deleted_at":
+					if typeStr != "int64" {
+						return StructInfo{}, fmt.Err("deleted_at field must be int64:", structName+"."+fieldName)
+					}
+					if softDeleteFound {
+						return StructInfo{}, fmt.Err("multiple deleted_at fields in", structName)
+					}
+					softDeleteFound = true
+					softDelete = true
+				case p == "created", p == "updated":
+					if typeStr != "int64" {
+						return StructInfo{}, fmt.Err(p, "field must be int64:", structName+"."+fieldName)
+					}
+					found := &createdFound
+					if p == "updated" {
+						found = &updatedFound
+					}
+					if *found {
+						return StructInfo{}, fmt.Err("multiple", p, "fields in", structName)
+					}
+					*found = true
+					created = created || p == "created"
+					updated = updated || p == "updated"
+					if created && updated {
+						return StructInfo{}, fmt.Err("created and updated on the same field:", structName+"."+fieldName)
+					}
 				case fmt.HasPrefix(p, "ref="):
 					refVal := fmt.Convert(p).TrimPrefix("ref=").String()
 					refParts := fmt.Convert(refVal).Split(":")
@@ -323,6 +465,13 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 			IsPK:       fieldIsPK,
 			GoType:     typeStr,
 			OmitEmpty:  omitEmpty,
+			Indexes:    indexes,
+			Uniques:    uniques,
+			Default:    def,
+			Version:    version,
+			SoftDelete: softDelete,
+			Created:    created,
+			Updated:    updated,
 		}
 
 		if isForm {
@@ -358,9 +507,118 @@ func (o *Ormc) ParseStruct(structName string, goFile string) (StructInfo, error)
 		info.Fields = append(info.Fields, fi)
 	}
 
+	indexes, err := collectIndexes(info.Fields)
+	if err != nil {
+		return StructInfo{}, err
+	}
+	info.Indexes = indexes
+
 	return info, nil
 }
 
+// parseViewDirective reads the options of an "// ormc:view" comment:
+// name=<view name> and as="<SELECT body>" (a Go quoted string, last).
+// Both are optional.
+func parseViewDirective(text string) (name, query string, err error) {
+	args := text[strings.Index(text, "ormc:view")+len("ormc:view"):]
+	if i := strings.Index(args, "as="); i >= 0 {
+		query, err = strconv.Unquote(strings.TrimSpace(args[i+len("as="):]))
+		if err != nil {
+			return "", "", fmt.Err("as= must be a quoted string")
+		}
+		query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+		args = args[:i]
+	}
+	for _, opt := range strings.Fields(args) {
+		if v, ok := strings.CutPrefix(opt, "name="); ok {
+			name = v
+		} else {
+			return "", "", fmt.Err("unknown ormc:view option", opt)
+		}
+	}
+	return name, query, nil
+}
+
+// isIntegerGoType reports whether goType is one of the supported integer types.
+func isIntegerGoType(goType string) bool {
+	switch goType {
+	case "int", "int32", "int64", "uint", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// validateDefault checks that a db:"default=..." literal fits the Go type:
+// integers and floats must parse, bools are true/false and strings must be
+// single-quoted, e.g. default='pending'. Other types cannot have defaults.
+func validateDefault(def, goType string) error {
+	if def == "" {
+		return fmt.Err("default value cannot be empty")
+	}
+	switch goType {
+	case "int", "int32", "int64":
+		if _, err := fmt.Convert(def).Int64(); err != nil {
+			return fmt.Err("invalid integer default", def)
+		}
+	case "uint", "uint32", "uint64":
+		if n, err := fmt.Convert(def).Int64(); err != nil || n < 0 {
+			return fmt.Err("invalid unsigned default", def)
+		}
+	case "float32", "float64":
+		if _, err := fmt.Convert(def).Float64(); err != nil {
+			return fmt.Err("invalid float default", def)
+		}
+	case "bool":
+		if def != "true" && def != "false" {
+			return fmt.Err("invalid bool default", def, "(want true or false)")
+		}
+	case "string":
+		if len(def) < 2 || def[0] != '\'' || def[len(def)-1] != '\'' {
+			return fmt.Err("string default must be single-quoted:", def)
+		}
+	default:
+		return fmt.Err("default not allowed on type", goType)
+	}
+	return nil
+}
+
+// collectIndexes groups the per-field index and unique= names into Index
+// declarations, in order of first appearance. A name may not be used for
+// both a plain index and a unique constraint.
+func collectIndexes(fields []FieldInfo) ([]Index, error) {
+	var out []Index
+	pos := make(map[string]int)
+	add := func(name, column string, unique bool) error {
+		if name == "" {
+			return fmt.Err("index name cannot be empty")
+		}
+		i, ok := pos[name]
+		if !ok {
+			pos[name] = len(out)
+			out = append(out, Index{Name: name, Columns: []string{column}, Unique: unique})
+			return nil
+		}
+		if out[i].Unique != unique {
+			return fmt.Err("index", name, "declared as both index and unique")
+		}
+		out[i].Columns = append(out[i].Columns, column)
+		return nil
+	}
+	for _, f := range fields {
+		for _, name := range f.Indexes {
+			if err := add(name, f.ColumnName, false); err != nil {
+				return nil, err
+			}
+		}
+		for _, name := range f.Uniques {
+			if err := add(name, f.ColumnName, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
 var defaultWidgets = map[string]string{
 	"string":  "input.Text()",
 	"int":     "input.Number()",
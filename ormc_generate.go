@@ -4,6 +4,7 @@ package orm
 
 import (
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/tinywasm/fmt"
@@ -21,11 +22,15 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 	buf.Write(fmt.Sprintf("package %s\n\n", infos[0].PackageName))
 
 	hasModel := false
+	hasResult := false
 	hasWidget := false
 	for _, info := range infos {
-		if !info.FormOnly {
+		if isDBModel(info) {
 			hasModel = true
 		}
+		if info.Result {
+			hasResult = true
+		}
 		for _, f := range info.Fields {
 			if f.WidgetConstructor != "" {
 				hasWidget = true
@@ -34,8 +39,11 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 	}
 
 	buf.Write("import (\n")
-	buf.Write("\t\"github.com/tinywasm/fmt\"\n")
 	if hasModel {
+		buf.Write("\t\"iter\"\n\n")
+	}
+	buf.Write("\t\"github.com/tinywasm/fmt\"\n")
+	if hasModel || hasResult {
 		buf.Write("\t\"github.com/tinywasm/orm\"\n")
 	}
 	if hasWidget {
@@ -44,33 +52,24 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 	buf.Write(")\n\n")
 
 	for _, info := range infos {
-		if !info.FormOnly {
+		if isDBModel(info) {
 			// Model Interface Methods
 			if !info.ModelNameDeclared {
 				buf.Write(fmt.Sprintf("func (m *%s) ModelName() string {\n", info.Name))
 				buf.Write(fmt.Sprintf("\treturn \"%s\"\n", info.ModelName))
 				buf.Write("}\n\n")
 			}
+			if info.View {
+				buf.Write(fmt.Sprintf("func (m *%s) ViewQuery() string {\n", info.Name))
+				buf.Write(fmt.Sprintf("\treturn %s\n", strconv.Quote(info.ViewQuery)))
+				buf.Write("}\n\n")
+			}
 		}
 
 		buf.Write(fmt.Sprintf("var _schema%s = []fmt.Field{\n", info.Name))
 		for _, f := range info.Fields {
-			typeStr := "fmt.FieldText"
-			switch f.Type {
-			case fmt.FieldInt:
-				typeStr = "fmt.FieldInt"
-			case fmt.FieldFloat:
-				typeStr = "fmt.FieldFloat"
-			case fmt.FieldBool:
-				typeStr = "fmt.FieldBool"
-			case fmt.FieldBlob:
-				typeStr = "fmt.FieldBlob"
-			case fmt.FieldStruct:
-				typeStr = "fmt.FieldStruct"
-			}
-
-			buf.Write(fmt.Sprintf("\t\t{Name: \"%s\", Type: %s", f.ColumnName, typeStr))
-			if !info.FormOnly && (f.PK || f.Unique || f.AutoInc) {
+			buf.Write(fmt.Sprintf("\t\t{Name: \"%s\", Type: %s", f.ColumnName, fieldTypeLiteral(f.Type)))
+			if isDBModel(info) && (f.PK || f.Unique || f.AutoInc) {
 				buf.Write(", DB: &fmt.FieldDB{")
 				var parts []string
 				if f.PK {
@@ -101,6 +100,24 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 
 		buf.Write(fmt.Sprintf("func (m *%s) Schema() []fmt.Field { return _schema%s }\n\n", info.Name, info.Name))
 
+		if isDBModel(info) && !info.View && hasFieldExt(info) {
+			buf.Write(fmt.Sprintf("var _schemaExt%s = []orm.FieldExt{\n", info.Name))
+			for i, f := range info.Fields {
+				buf.Write(fmt.Sprintf("\t{Field: _schema%s[%d]%s},\n", info.Name, i, fieldExtAttrs(FieldExt{Ref: f.Ref, RefColumn: f.RefColumn, Default: f.Default, Version: f.Version, SoftDelete: f.SoftDelete, Created: f.Created, Updated: f.Updated})))
+			}
+			buf.Write("}\n\n")
+			buf.Write(fmt.Sprintf("func (m *%s) SchemaExt() []orm.FieldExt { return _schemaExt%s }\n\n", info.Name, info.Name))
+		}
+
+		if isDBModel(info) && !info.View && len(info.Indexes) > 0 {
+			buf.Write(fmt.Sprintf("var _indexes%s = []orm.Index{\n", info.Name))
+			for _, idx := range info.Indexes {
+				buf.Write(fmt.Sprintf("\t%s,\n", indexLiteral(idx)))
+			}
+			buf.Write("}\n\n")
+			buf.Write(fmt.Sprintf("func (m *%s) Indexes() []orm.Index { return _indexes%s }\n\n", info.Name, info.Name))
+		}
+
 		buf.Write(fmt.Sprintf("func (m *%s) Pointers() []any {\n", info.Name))
 		buf.Write("\treturn []any{\n")
 		for _, f := range info.Fields {
@@ -110,7 +127,7 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 		buf.Write("}\n\n")
 
 		hasValidation := info.IsForm
-		if !hasValidation {
+		if !hasValidation && !info.Result && !info.View {
 			for _, f := range info.Fields {
 				if f.NotNull || f.Letters || f.Numbers || f.Tilde || f.Spaces ||
 					len(f.Extra) > 0 || f.Minimum > 0 || f.Maximum > 0 {
@@ -126,7 +143,14 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 			buf.Write("}\n\n")
 		}
 
-		if !info.FormOnly {
+		if info.Result {
+			buf.Write(fmt.Sprintf("// ReadAll%s reads the rows of r into %s result rows.\n", info.Name, info.Name))
+			buf.Write(fmt.Sprintf("func ReadAll%s(r orm.ResultReader) ([]*%s, error) {\n", info.Name, info.Name))
+			buf.Write(fmt.Sprintf("\treturn orm.CollectAs[%s](r)\n", info.Name))
+			buf.Write("}\n\n")
+		}
+
+		if isDBModel(info) {
 			// Metadata Descriptors
 			buf.Write(fmt.Sprintf("var %s_ = struct {\n", info.Name))
 			buf.Write("\tModelName string\n")
@@ -140,6 +164,20 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 			}
 			buf.Write("}\n\n")
 
+			// Named Scopes
+			if len(info.Scopes) > 0 {
+				buf.Write(fmt.Sprintf("// %sScope holds the named query scopes declared on %s, for orm.QB.Scope.\n", info.Name, info.Name))
+				buf.Write(fmt.Sprintf("var %sScope = struct {\n", info.Name))
+				for _, sc := range info.Scopes {
+					buf.Write(fmt.Sprintf("\t%s func(*orm.QB) *orm.QB\n", sc))
+				}
+				buf.Write("}{\n")
+				for _, sc := range info.Scopes {
+					buf.Write(fmt.Sprintf("\t%s: (&%s{}).Scope%s,\n", sc, info.Name, sc))
+				}
+				buf.Write("}\n\n")
+			}
+
 			// Typed Read Operations
 			buf.Write(fmt.Sprintf("func ReadOne%s(qb *orm.QB, model *%s) (*%s, error) {\n", info.Name, info.Name, info.Name))
 			buf.Write("\terr := qb.ReadOne()\n")
@@ -150,12 +188,20 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 			buf.Write("}\n\n")
 
 			buf.Write(fmt.Sprintf("func ReadAll%s(qb *orm.QB) ([]*%s, error) {\n", info.Name, info.Name))
-			buf.Write(fmt.Sprintf("\tvar results []*%s\n", info.Name))
-			buf.Write("\terr := qb.ReadAll(\n")
-			buf.Write(fmt.Sprintf("\t\tfunc() fmt.Model { return &%s{} },\n", info.Name))
-			buf.Write(fmt.Sprintf("\t\tfunc(m fmt.Model) { results = append(results, m.(*%s)) },\n", info.Name))
-			buf.Write("\t)\n")
-			buf.Write("\treturn results, err\n")
+			buf.Write(fmt.Sprintf("\treturn orm.Collect[%s](qb)\n", info.Name))
+			buf.Write("}\n\n")
+
+			buf.Write(fmt.Sprintf("// Iter%s streams the rows of qb. The *%s passed to db.Query is reused for\n", info.Name, info.Name))
+			buf.Write("// every row; breaking out of the loop closes the rows.\n")
+			buf.Write(fmt.Sprintf("func Iter%s(qb *orm.QB) iter.Seq2[*%s, error] {\n", info.Name, info.Name))
+			buf.Write(fmt.Sprintf("\treturn func(yield func(*%s, error) bool) {\n", info.Name))
+			buf.Write("\t\tfor m, err := range qb.Iter() {\n")
+			buf.Write(fmt.Sprintf("\t\t\tv, _ := m.(*%s)\n", info.Name))
+			buf.Write("\t\t\tif !yield(v, err) {\n")
+			buf.Write("\t\t\t\treturn\n")
+			buf.Write("\t\t\t}\n")
+			buf.Write("\t\t}\n")
+			buf.Write("\t}\n")
 			buf.Write("}\n\n")
 
 			for _, rel := range info.Relations {
@@ -179,3 +225,76 @@ func (o *Ormc) GenerateForFile(infos []StructInfo, sourceFile string) error {
 	outName := fmt.Convert(sourceFile).TrimSuffix(".go").String() + "_orm.go"
 	return os.WriteFile(outName, buf.Bytes(), 0644)
 }
+
+// fieldTypeLiteral returns the Go expression for a fmt.FieldType constant.
+func fieldTypeLiteral(t fmt.FieldType) string {
+	switch t {
+	case fmt.FieldInt:
+		return "fmt.FieldInt"
+	case fmt.FieldFloat:
+		return "fmt.FieldFloat"
+	case fmt.FieldBool:
+		return "fmt.FieldBool"
+	case fmt.FieldBlob:
+		return "fmt.FieldBlob"
+	case fmt.FieldStruct:
+		return "fmt.FieldStruct"
+	}
+	return "fmt.FieldText"
+}
+
+// indexLiteral returns the Go expression for an orm.Index value without the type name.
+func indexLiteral(idx Index) string {
+	cols := make([]string, len(idx.Columns))
+	for i, c := range idx.Columns {
+		cols[i] = "\"" + c + "\""
+	}
+	lit := fmt.Sprintf("{Name: \"%s\", Columns: []string{%s}", idx.Name, strings.Join(cols, ", "))
+	if idx.Unique {
+		lit += ", Unique: true"
+	}
+	return lit + "}"
+}
+
+// isDBModel reports whether info maps a table: neither form-only nor a result row.
+func isDBModel(info StructInfo) bool {
+	return !info.FormOnly && !info.Result
+}
+
+// hasFieldExt reports whether any field carries metadata that only fits in orm.FieldExt.
+func hasFieldExt(info StructInfo) bool {
+	for _, f := range info.Fields {
+		if f.Ref != "" || f.Default != "" || f.Version || f.SoftDelete || f.Created || f.Updated {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldExtAttrs renders the non-zero orm.FieldExt attributes of e (other than
+// Field), each prefixed by ", ".
+func fieldExtAttrs(e FieldExt) string {
+	var out string
+	if e.Ref != "" {
+		out += ", Ref: " + strconv.Quote(e.Ref)
+	}
+	if e.RefColumn != "" {
+		out += ", RefColumn: " + strconv.Quote(e.RefColumn)
+	}
+	if e.Default != "" {
+		out += ", Default: " + strconv.Quote(e.Default)
+	}
+	if e.Version {
+		out += ", Version: true"
+	}
+	if e.SoftDelete {
+		out += ", SoftDelete: true"
+	}
+	if e.Created {
+		out += ", Created: true"
+	}
+	if e.Updated {
+		out += ", Updated: true"
+	}
+	return out
+}
@@ -4,8 +4,9 @@ package orm
 
 // Ormc is the code generator handler for the ormc tool.
 type Ormc struct {
-	logFn   func(messages ...any)
-	rootDir string
+	logFn         func(messages ...any)
+	rootDir       string
+	migrationsDir string
 }
 
 // NewOrmc creates a new Ormc handler with rootDir defaulting to ".".
@@ -0,0 +1,372 @@
+//go:build !wasm
+
+package orm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tinywasm/fmt"
+)
+
+// SchemaSnapshot is the checked-in JSON record of the DB schema that the last
+// migration was generated against. `ormc migrate new` diffs it with the models.
+type SchemaSnapshot struct {
+	Tables []TableSnapshot `json:"tables"`
+}
+
+// TableSnapshot records one table of a SchemaSnapshot.
+type TableSnapshot struct {
+	Name    string           `json:"name"`
+	Columns []ColumnSnapshot `json:"columns"`
+	Indexes []Index          `json:"indexes,omitempty"`
+}
+
+// ColumnSnapshot records one column of a TableSnapshot.
+type ColumnSnapshot struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"` // fmt.FieldType.String(), e.g. "int"
+	PK        bool   `json:"pk,omitempty"`
+	Unique    bool   `json:"unique,omitempty"`
+	AutoInc   bool   `json:"autoinc,omitempty"`
+	NotNull   bool   `json:"not_null,omitempty"`
+	Ref       string `json:"ref,omitempty"`
+	RefColumn string `json:"ref_column,omitempty"`
+	Default   string `json:"default,omitempty"`
+}
+
+// MigrationStep is a single schema change produced by DiffSchemas.
+type MigrationStep struct {
+	Kind   string         // "create_table", "drop_table", "add_column", "drop_column", "alter_column", "create_index", "drop_index"
+	Table  TableSnapshot  // full table for create_table; name only is relevant otherwise
+	Column ColumnSnapshot // the affected column for *_column steps
+	Index  Index          // the affected index for *_index steps
+}
+
+// snapshotFileName is the schema snapshot kept next to the migration files.
+const snapshotFileName = "schema.json"
+
+// SetMigrationsDir sets the directory used by MigrateNew for migration files
+// and the schema snapshot. Defaults to "<rootDir>/migrations".
+func (o *Ormc) SetMigrationsDir(dir string) {
+	o.migrationsDir = dir
+}
+
+func (o *Ormc) migrationsPath() string {
+	if o.migrationsDir != "" {
+		return o.migrationsDir
+	}
+	return filepath.Join(o.rootDir, "migrations")
+}
+
+// Snapshot builds a SchemaSnapshot from the collected structs.
+// Form-only, result and view structs are skipped; tables are sorted by name.
+func (o *Ormc) Snapshot(all map[string]StructInfo) SchemaSnapshot {
+	var snap SchemaSnapshot
+	for _, info := range all {
+		if info.FormOnly || info.Result || info.View {
+			continue
+		}
+		t := TableSnapshot{Name: info.ModelName, Indexes: info.Indexes}
+		for _, f := range info.Fields {
+			t.Columns = append(t.Columns, ColumnSnapshot{
+				Name:      f.ColumnName,
+				Type:      f.Type.String(),
+				PK:        f.PK,
+				Unique:    f.Unique,
+				AutoInc:   f.AutoInc,
+				NotNull:   f.NotNull,
+				Ref:       f.Ref,
+				RefColumn: f.RefColumn,
+				Default:   f.Default,
+			})
+		}
+		snap.Tables = append(snap.Tables, t)
+	}
+	sort.Slice(snap.Tables, func(i, j int) bool { return snap.Tables[i].Name < snap.Tables[j].Name })
+	return snap
+}
+
+// DiffSchemas returns the steps that turn the old schema into the new one.
+// Created tables and column changes come first, dropped tables last. Tables
+// are visited parents first by their Ref columns, so a table is created after
+// the tables it references and dropped before them.
+// Within a table, stale indexes are dropped before and new ones created
+// after the column steps.
+func DiffSchemas(old, new SchemaSnapshot) []MigrationStep {
+	oldTables := make(map[string]TableSnapshot, len(old.Tables))
+	for _, t := range old.Tables {
+		oldTables[t.Name] = t
+	}
+	newTables := make(map[string]bool, len(new.Tables))
+
+	var steps []MigrationStep
+	for _, nt := range orderByRefs(new.Tables) {
+		newTables[nt.Name] = true
+		ot, ok := oldTables[nt.Name]
+		if !ok {
+			steps = append(steps, MigrationStep{Kind: "create_table", Table: nt})
+			for _, idx := range nt.Indexes {
+				steps = append(steps, MigrationStep{Kind: "create_index", Table: nt, Index: idx})
+			}
+			continue
+		}
+
+		// Indexes are dropped before column changes and created after them.
+		// A changed index is dropped and created again under the same name.
+		for _, idx := range ot.Indexes {
+			if n, ok := findIndex(nt.Indexes, idx.Name); !ok || !sameIndex(idx, n) {
+				steps = append(steps, MigrationStep{Kind: "drop_index", Table: nt, Index: idx})
+			}
+		}
+
+		oldCols := make(map[string]ColumnSnapshot, len(ot.Columns))
+		for _, c := range ot.Columns {
+			oldCols[c.Name] = c
+		}
+		newCols := make(map[string]bool, len(nt.Columns))
+		for _, c := range nt.Columns {
+			newCols[c.Name] = true
+			oc, ok := oldCols[c.Name]
+			switch {
+			case !ok:
+				steps = append(steps, MigrationStep{Kind: "add_column", Table: nt, Column: c})
+			case oc != c:
+				steps = append(steps, MigrationStep{Kind: "alter_column", Table: nt, Column: c})
+			}
+		}
+		for _, c := range ot.Columns {
+			if !newCols[c.Name] {
+				steps = append(steps, MigrationStep{Kind: "drop_column", Table: nt, Column: c})
+			}
+		}
+
+		for _, idx := range nt.Indexes {
+			if o, ok := findIndex(ot.Indexes, idx.Name); !ok || !sameIndex(o, idx) {
+				steps = append(steps, MigrationStep{Kind: "create_index", Table: nt, Index: idx})
+			}
+		}
+	}
+
+	oldOrder := orderByRefs(old.Tables)
+	for i := len(oldOrder) - 1; i >= 0; i-- {
+		if ot := oldOrder[i]; !newTables[ot.Name] {
+			steps = append(steps, MigrationStep{Kind: "drop_table", Table: ot})
+		}
+	}
+	return steps
+}
+
+// orderByRefs returns tables with every table after the tables its Ref
+// columns point to, keeping the given order otherwise. Self references and
+// references outside tables are ignored; a cycle is broken where it is found.
+func orderByRefs(tables []TableSnapshot) []TableSnapshot {
+	byName := make(map[string]TableSnapshot, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+	visited := make(map[string]bool, len(tables))
+	out := make([]TableSnapshot, 0, len(tables))
+	var visit func(t TableSnapshot)
+	visit = func(t TableSnapshot) {
+		if visited[t.Name] {
+			return
+		}
+		visited[t.Name] = true
+		for _, c := range t.Columns {
+			if parent, ok := byName[c.Ref]; ok {
+				visit(parent)
+			}
+		}
+		out = append(out, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return out
+}
+
+// MigrateNew compares the models under rootDir with the schema snapshot and
+// writes a Go migration file named "<timestamp>_<name>.go" holding the steps.
+// The snapshot is updated afterwards. Returns the path of the written file.
+func (o *Ormc) MigrateNew(name string) (string, error) {
+	name = fmt.Convert(name).SnakeLow().String()
+	if name == "" {
+		return "", fmt.Err("migration name cannot be empty")
+	}
+
+	all, _, _, err := o.collectAllStructs()
+	if err != nil {
+		return "", fmt.Err(err, "error walking directory")
+	}
+	if len(all) == 0 {
+		return "", fmt.Err("no models found")
+	}
+
+	dir := o.migrationsPath()
+	snapPath := filepath.Join(dir, snapshotFileName)
+
+	var old SchemaSnapshot
+	if data, err := os.ReadFile(snapPath); err == nil {
+		if err := json.Unmarshal(data, &old); err != nil {
+			return "", fmt.Err(err, "invalid schema snapshot", snapPath)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	current := o.Snapshot(all)
+	steps := DiffSchemas(old, current)
+	if len(steps) == 0 {
+		return "", fmt.Err("no schema changes")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	stamp := time.Now().UTC().Format("20060102150405")
+	funcName := "Migrate" + stamp + camelUp(name)
+	src := writeMigration(filepath.Base(dir), funcName, name, steps)
+
+	outPath := filepath.Join(dir, stamp+"_"+name+".go")
+	if err := os.WriteFile(outPath, src, 0644); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(snapPath, append(data, '\n'), 0644); err != nil {
+		return "", err
+	}
+
+	o.log(fmt.Sprintf("Wrote migration %s (%d steps)", outPath, len(steps)))
+	return outPath, nil
+}
+
+// writeMigration renders the migration source for steps.
+func writeMigration(pkg, funcName, name string, steps []MigrationStep) []byte {
+	buf := fmt.Convert()
+	buf.Write("// Code generated by ormc migrate new. Review before committing.\n\n")
+	buf.Write(fmt.Sprintf("package %s\n\n", pkg))
+
+	// Only steps rendering columns (columnsLiteral) use fmt.
+	usesFmt := false
+	for _, s := range steps {
+		switch s.Kind {
+		case "create_table", "add_column", "alter_column":
+			usesFmt = true
+		}
+	}
+	buf.Write("import (\n")
+	if usesFmt {
+		buf.Write("\t\"github.com/tinywasm/fmt\"\n")
+	}
+	buf.Write("\t\"github.com/tinywasm/orm\"\n")
+	buf.Write(")\n\n")
+
+	buf.Write(fmt.Sprintf("// %s applies the \"%s\" schema changes.\n", funcName, name))
+	buf.Write(fmt.Sprintf("func %s(db *orm.DB) error {\n", funcName))
+	for _, s := range steps {
+		var call string
+		switch s.Kind {
+		case "create_table":
+			call = fmt.Sprintf("db.CreateTable(orm.TableModel(\"%s\",%s))", s.Table.Name, columnsLiteral(s.Table.Columns...))
+		case "drop_table":
+			call = fmt.Sprintf("db.DropTable(orm.TableModel(\"%s\"))", s.Table.Name)
+		case "add_column":
+			call = fmt.Sprintf("db.AddColumn(orm.TableModel(\"%s\",%s), \"%s\")", s.Table.Name, columnsLiteral(s.Column), s.Column.Name)
+		case "alter_column":
+			call = fmt.Sprintf("db.AlterColumn(orm.TableModel(\"%s\",%s), \"%s\")", s.Table.Name, columnsLiteral(s.Column), s.Column.Name)
+		case "drop_column":
+			call = fmt.Sprintf("db.DropColumn(orm.TableModel(\"%s\"), \"%s\")", s.Table.Name, s.Column.Name)
+		case "create_index":
+			call = fmt.Sprintf("db.CreateIndex(orm.TableModel(\"%s\"), orm.Index%s)", s.Table.Name, indexLiteral(s.Index))
+		case "drop_index":
+			call = fmt.Sprintf("db.DropIndex(orm.TableModel(\"%s\"), \"%s\")", s.Table.Name, s.Index.Name)
+		}
+		buf.Write(fmt.Sprintf("\tif err := %s; err != nil {\n", call))
+		buf.Write("\t\treturn err\n")
+		buf.Write("\t}\n")
+	}
+	buf.Write("\treturn nil\n")
+	buf.Write("}\n")
+	return buf.Bytes()
+}
+
+func findIndex(list []Index, name string) (Index, bool) {
+	for _, idx := range list {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return Index{}, false
+}
+
+func sameIndex(a, b Index) bool {
+	if a.Name != b.Name || a.Unique != b.Unique || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	for i := range a.Columns {
+		if a.Columns[i] != b.Columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// columnsLiteral renders cols as variadic orm.FieldExt arguments, one per line.
+func columnsLiteral(cols ...ColumnSnapshot) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, c := range cols {
+		b.WriteString(fmt.Sprintf("\t\torm.FieldExt{Field: fmt.Field{Name: \"%s\", Type: %s", c.Name, fieldTypeLiteral(parseFieldType(c.Type))))
+		if c.PK || c.Unique || c.AutoInc {
+			var parts []string
+			if c.PK {
+				parts = append(parts, "PK: true")
+			}
+			if c.Unique {
+				parts = append(parts, "Unique: true")
+			}
+			if c.AutoInc {
+				parts = append(parts, "AutoInc: true")
+			}
+			b.WriteString(", DB: &fmt.FieldDB{" + strings.Join(parts, ", ") + "}")
+		}
+		if c.NotNull {
+			b.WriteString(", NotNull: true")
+		}
+		b.WriteString("}" + fieldExtAttrs(FieldExt{Ref: c.Ref, RefColumn: c.RefColumn, Default: c.Default}) + "},\n")
+	}
+	b.WriteString("\t")
+	return b.String()
+}
+
+// parseFieldType is the inverse of fmt.FieldType.String().
+func parseFieldType(s string) fmt.FieldType {
+	for t := fmt.FieldText; t <= fmt.FieldIntSlice; t++ {
+		if t.String() == s {
+			return t
+		}
+	}
+	return fmt.FieldText
+}
+
+// camelUp converts a snake_case name into CamelCase, e.g. "add_email" → "AddEmail".
+func camelUp(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// Page is one page of results returned by Paginate.
+type Page[T any] struct {
+	Items   []*T
+	Total   int64 // rows matching the query on all pages
+	Page    int   // 1-based page number
+	PerPage int
+	HasNext bool
+}
+
+// Paginate reads page number page (1-based; lower values mean 1) of perPage
+// rows matching qb, and counts the rows on all pages. Both queries use the
+// same conditions and run in one DB.Tx when the executor supports
+// transactions, or directly on a DB already inside one. The Limit and Offset
+// of qb are replaced; qb is not modified.
+func Paginate[T any, PT interface {
+	*T
+	fmt.Model
+}](qb *QB, page, perPage int) (Page[T], error) {
+	if perPage < 1 {
+		return Page[T]{}, fmt.Err("perPage", "must be positive")
+	}
+	if page < 1 {
+		page = 1
+	}
+	p := Page[T]{Page: page, PerPage: perPage}
+
+	run := func(db *DB) error {
+		q := *qb
+		q.db = db
+		q.limit = perPage
+		q.offset = (page - 1) * perPage
+
+		total, err := q.Count()
+		if err != nil {
+			return err
+		}
+		p.Total = total
+		items, err := Collect[T, PT](&q)
+		if err != nil {
+			return err
+		}
+		p.Items = items
+		p.HasNext = int64(page*perPage) < total
+		return nil
+	}
+
+	// A DB already inside a transaction runs both queries in it, on the
+	// primary; whether they see one snapshot depends on its isolation level.
+	var err error
+	if _, ok := qb.db.exec.(TxExecutor); ok && qb.db.txDepth == 0 {
+		err = qb.db.Tx(run)
+	} else {
+		err = run(qb.db)
+	}
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return p, nil
+}
@@ -1,6 +1,11 @@
 package orm
 
-import "github.com/tinywasm/fmt"
+import (
+	"errors"
+	"iter"
+
+	"github.com/tinywasm/fmt"
+)
 
 // QB represents a query builder.
 // Consumers hold a *QB reference in variables for incremental building.
@@ -13,6 +18,10 @@ type QB struct {
 	limit   int
 	offset  int
 	nextOr  bool
+	deleted deletedFilter
+	keyset  uint8
+	cursor  Cursor
+	primary bool
 }
 
 // Clause represents an intermediate state for building a query condition.
@@ -124,55 +133,110 @@ func (qb *QB) GroupBy(columns ...string) *QB {
 	return qb
 }
 
+// Scope applies fns to qb in order, so reusable filters chain like built-in
+// clauses: db.Query(&u).Scope(UserScope.Active, recent).Limit(10).
+func (qb *QB) Scope(fns ...func(*QB) *QB) *QB {
+	for _, fn := range fns {
+		qb = fn(qb)
+	}
+	return qb
+}
+
 // ReadOne executes the query and returns a single result.
+// The model's AfterRead hook runs after the scan.
 func (qb *QB) ReadOne() error {
 	if err := validateQuery(ActionReadOne, qb.model); err != nil {
 		return err
 	}
-	q := Query{
-		Action:     ActionReadOne,
-		Table:      qb.model.ModelName(),
-		Conditions: qb.conds,
-		OrderBy:    qb.orderBy,
-		GroupBy:    qb.groupBy,
-		Limit:      1, // Force limit 1
-		Offset:     qb.offset,
+	q, err := qb.readQuery(ActionReadOne)
+	if err != nil {
+		return err
 	}
-	plan, err := qb.db.compiler.Compile(q, qb.model)
+	q.Limit = 1 // Force limit 1
+	plan, exec, err := qb.db.compile(qb.reader(), q, qb.model)
 	if err != nil {
 		return err
 	}
 
-	row := qb.db.exec.QueryRow(plan.Query, plan.Args...)
+	row := exec.QueryRow(plan.Query, plan.Args...)
 	if err := row.Scan(qb.model.Pointers()...); err != nil {
 		return err
 	}
-	return nil
+	return qb.db.hook(hookAfterRead, qb.model)
 }
 
 // ReadAll executes the query and returns all results.
+// Each row's AfterRead hook runs before onRow; a hook error stops the read.
 func (qb *QB) ReadAll(new func() fmt.Model, onRow func(fmt.Model)) error {
+	return qb.readAll(new, func(m fmt.Model) bool {
+		onRow(m)
+		return true
+	})
+}
+
+// ReadAllE is ReadAll with a callback that can end the read: when onRow
+// returns an error the rows are closed immediately and the error is returned,
+// except ErrStop (or an error wrapping it), which ends the read without error.
+func (qb *QB) ReadAllE(new func() fmt.Model, onRow func(fmt.Model) error) error {
+	var cbErr error
+	err := qb.readAll(new, func(m fmt.Model) bool {
+		cbErr = onRow(m)
+		return cbErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	if errors.Is(cbErr, ErrStop) {
+		return nil
+	}
+	return cbErr
+}
+
+// Iter executes the query and streams its rows. Each row is scanned into the
+// model passed to DB.Query, so the yielded value is overwritten by the next
+// row; copy it to keep it. Breaking out of the loop closes the rows. A read
+// error is yielded once, with a nil model, and ends the sequence.
+func (qb *QB) Iter() iter.Seq2[fmt.Model, error] {
+	return func(yield func(fmt.Model, error) bool) {
+		stopped := false
+		err := qb.readAll(
+			func() fmt.Model { return qb.model },
+			func(m fmt.Model) bool {
+				stopped = !yield(m, nil)
+				return !stopped
+			},
+		)
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// readAll is ReadAll with early stop: reading ends, and Rows are closed, when
+// onRow returns false.
+func (qb *QB) readAll(new func() fmt.Model, onRow func(fmt.Model) bool) error {
 	if err := validateQuery(ActionReadAll, qb.model); err != nil {
 		return err
 	}
-	q := Query{
-		Action:     ActionReadAll,
-		Table:      qb.model.ModelName(),
-		Conditions: qb.conds,
-		OrderBy:    qb.orderBy,
-		GroupBy:    qb.groupBy,
-		Limit:      qb.limit,
-		Offset:     qb.offset,
+	q, err := qb.readQuery(ActionReadAll)
+	if err != nil {
+		return err
 	}
-	plan, err := qb.db.compiler.Compile(q, qb.model)
+	plan, exec, err := qb.db.compile(qb.reader(), q, qb.model)
 	if err != nil {
 		return err
 	}
 
-	rows, err := qb.db.exec.Query(plan.Query, plan.Args...)
+	rows, err := exec.Query(plan.Query, plan.Args...)
 	if err != nil {
 		return err
 	}
+	return qb.db.scanRows(rows, new, onRow)
+}
+
+// scanRows reads rows into models from new, running each AfterRead hook before
+// onRow, and closes rows. Reading ends early when onRow returns false.
+func (db *DB) scanRows(rows Rows, new func() fmt.Model, onRow func(fmt.Model) bool) error {
 	defer rows.Close()
 
 	for rows.Next() {
@@ -180,7 +244,73 @@ func (qb *QB) ReadAll(new func() fmt.Model, onRow func(fmt.Model)) error {
 		if err := rows.Scan(m.Pointers()...); err != nil {
 			return err
 		}
-		onRow(m)
+		if err := db.hook(hookAfterRead, m); err != nil {
+			return err
+		}
+		if !onRow(m) {
+			return nil
+		}
 	}
 	return rows.Err()
 }
+
+// Count returns the number of rows matching the query conditions, or of
+// groups when GroupBy is set. Order, Limit, Offset and keyset cursors are
+// ignored.
+func (qb *QB) Count() (int64, error) {
+	if err := validateQuery(ActionCount, qb.model); err != nil {
+		return 0, err
+	}
+	q := Query{
+		Action:     ActionCount,
+		Table:      qb.model.ModelName(),
+		Conditions: qb.softDeleteFilter(qb.conds),
+		GroupBy:    qb.groupBy,
+	}
+	plan, exec, err := qb.db.compile(qb.reader(), q, qb.model)
+	if err != nil {
+		return 0, err
+	}
+	if len(q.GroupBy) > 0 {
+		// A grouped count returns one row per group.
+		rows, err := exec.Query(plan.Query, plan.Args...)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		var n int64
+		for rows.Next() {
+			n++
+		}
+		return n, rows.Err()
+	}
+	var n int64
+	if err := exec.QueryRow(plan.Query, plan.Args...).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// readQuery builds the Query of a read, with the keyset and soft-delete
+// conditions added to the builder's own.
+func (qb *QB) readQuery(action Action) (Query, error) {
+	q := Query{
+		Action:  action,
+		Table:   qb.model.ModelName(),
+		OrderBy: qb.orderBy,
+		GroupBy: qb.groupBy,
+		Limit:   qb.limit,
+		Offset:  qb.offset,
+	}
+	conds := qb.conds
+	if qb.keyset != keysetNone {
+		order, extra, err := qb.applyKeyset()
+		if err != nil {
+			return Query{}, err
+		}
+		q.OrderBy = order
+		conds = andAll(conds, extra...)
+	}
+	q.Conditions = qb.softDeleteFilter(conds)
+	return q, nil
+}
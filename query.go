@@ -12,6 +12,14 @@ const (
 	ActionCreateTable
 	ActionDropTable
 	ActionCreateDatabase
+	ActionAddColumn
+	ActionDropColumn
+	ActionAlterColumn
+	ActionCreateIndex
+	ActionDropIndex
+	ActionCount // SELECT COUNT(*) with the read conditions; the plan yields one integer row
+	ActionCreateView
+	ActionDropView
 )
 
 // Order represents a sort order for a query.
@@ -30,11 +38,14 @@ type Query struct {
 	Action     Action
 	Table      string
 	Database   string
-	Columns    []string
+	Columns    []string // writes: columns of Values; ActionReadAll: columns to select, empty for all
 	Values     []any
 	Conditions []Condition
 	OrderBy    []Order
 	GroupBy    []string
 	Limit      int
 	Offset     int
+	Index      Index    // ActionCreateIndex / ActionDropIndex only
+	Returning  []string // ActionCreate: DB-generated columns to report back (autoincrement PK)
+	ViewQuery  string   // ActionCreateView only: the SELECT defining the view
 }
@@ -0,0 +1,63 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// RawQuery is a hand-written SQL statement bound to a DB, for what the query
+// builder cannot express (CTEs, window functions). Created by DB.Raw.
+type RawQuery struct {
+	db    *DB
+	query string
+	args  []any
+}
+
+// Raw returns a RawQuery running query with args on the DB executor, as is:
+// neither the compiler nor the DB scopes see it. On a scoped DB (see
+// WithScope) its methods fail with ErrScoped; use Unscoped().Raw instead.
+func (db *DB) Raw(query string, args ...any) *RawQuery {
+	return &RawQuery{db: db, query: query, args: args}
+}
+
+// ReadOne runs the query and scans the first row into m.Pointers(), then
+// runs m's AfterRead hook.
+func (r *RawQuery) ReadOne(m fmt.Model) error {
+	if err := r.checkScope(); err != nil {
+		return err
+	}
+	if err := r.db.exec.QueryRow(r.query, r.args...).Scan(m.Pointers()...); err != nil {
+		return err
+	}
+	return r.db.hook(hookAfterRead, m)
+}
+
+// ReadAll runs the query and reads its rows exactly like QB.ReadAll: each row
+// is scanned into the Pointers() of a model from new() and passed to onRow
+// after its AfterRead hook.
+func (r *RawQuery) ReadAll(new func() fmt.Model, onRow func(fmt.Model)) error {
+	if err := r.checkScope(); err != nil {
+		return err
+	}
+	rows, err := r.db.exec.Query(r.query, r.args...)
+	if err != nil {
+		return err
+	}
+	return r.db.scanRows(rows, new, func(m fmt.Model) bool {
+		onRow(m)
+		return true
+	})
+}
+
+// Exec runs the statement and reports its Result. Executors that do not
+// implement ResultExecutor report -1 for both counters.
+func (r *RawQuery) Exec() (Result, error) {
+	if err := r.checkScope(); err != nil {
+		return unknownResult, err
+	}
+	return execResult(r.db.exec, Plan{Query: r.query, Args: r.args})
+}
+
+func (r *RawQuery) checkScope() error {
+	if len(r.db.scopes) > 0 {
+		return ErrScoped
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package orm
+
+import "sync/atomic"
+
+// Replica is a read replica configured with WithReplicas.
+type Replica struct {
+	Exec   Executor
+	Weight int // relative share of reads; values below 1 count as 1
+}
+
+// WithReplicas routes the reads of the query builder (ReadOne, ReadAll and
+// the helpers built on them, Count) to the replicas, rotating across them in
+// proportion to their weights: round-robin when the weights are equal.
+// Writes, DDL, Raw queries and everything inside Tx use the primary executor
+// passed to New, as do queries marked with QB.Primary.
+func WithReplicas(replicas ...Replica) Option {
+	return func(db *DB) {
+		if len(replicas) == 0 {
+			db.replicas = nil
+			return
+		}
+		r := &replicaSet{}
+		for _, rep := range replicas {
+			w := rep.Weight
+			if w < 1 {
+				w = 1
+			}
+			r.total += uint64(w)
+			r.replicas = append(r.replicas, Replica{Exec: rep.Exec, Weight: w})
+		}
+		db.replicas = r
+	}
+}
+
+// replicaSet picks replicas by weighted round-robin. It is shared by the
+// copies of a DB (scopes), so the rotation is global to the connection.
+type replicaSet struct {
+	replicas []Replica
+	total    uint64
+	next     atomic.Uint64
+}
+
+// pick returns the executor for the next read.
+func (r *replicaSet) pick() Executor {
+	n := (r.next.Add(1) - 1) % r.total
+	for _, rep := range r.replicas {
+		if n < uint64(rep.Weight) {
+			return rep.Exec
+		}
+		n -= uint64(rep.Weight)
+	}
+	return r.replicas[0].Exec
+}
+
+// Primary sends the query to the primary executor even when replicas are
+// configured, for reads that must see the caller's own writes.
+func (qb *QB) Primary() *QB {
+	qb.primary = true
+	return qb
+}
+
+// reader returns the executor for a read of qb.
+func (qb *QB) reader() Executor {
+	if qb.primary || qb.db.replicas == nil {
+		return qb.db.exec
+	}
+	return qb.db.replicas.pick()
+}
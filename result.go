@@ -0,0 +1,120 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// Result rows: report, aggregate and join shapes that are not tables are read
+// into plain fmt.Fielder values (generated by ormc for // ormc:result
+// structs) instead of models. They have no hooks and no write operations.
+
+// ResultReader is implemented by QB and RawQuery: queries whose rows can be
+// read into result rows.
+type ResultReader interface {
+	ReadAllAs(new func() fmt.Fielder, onRow func(fmt.Fielder)) error
+}
+
+// ColumnRows is an optional Rows extension reporting the column names of the
+// result set (sql.Rows implements it). Result rows are then scanned by column
+// name; otherwise by column order.
+type ColumnRows interface {
+	Rows
+	Columns() ([]string, error)
+}
+
+// ReadAllAs executes the query selecting the columns of the result row schema,
+// in order, from the builder's table, and scans each row into a Fielder from
+// new() before passing it to onRow.
+func (qb *QB) ReadAllAs(new func() fmt.Fielder, onRow func(fmt.Fielder)) error {
+	if err := validateQuery(ActionReadAll, qb.model); err != nil {
+		return err
+	}
+	q, err := qb.readQuery(ActionReadAll)
+	if err != nil {
+		return err
+	}
+	for _, f := range new().Schema() {
+		q.Columns = append(q.Columns, f.Name)
+	}
+	plan, exec, err := qb.db.compile(qb.reader(), q, qb.model)
+	if err != nil {
+		return err
+	}
+	rows, err := exec.Query(plan.Query, plan.Args...)
+	if err != nil {
+		return err
+	}
+	return scanResults(rows, new, onRow)
+}
+
+// ReadAllAs runs the raw query and scans each row into a Fielder from new()
+// before passing it to onRow.
+func (r *RawQuery) ReadAllAs(new func() fmt.Fielder, onRow func(fmt.Fielder)) error {
+	if err := r.checkScope(); err != nil {
+		return err
+	}
+	rows, err := r.db.exec.Query(r.query, r.args...)
+	if err != nil {
+		return err
+	}
+	return scanResults(rows, new, onRow)
+}
+
+// CollectAs reads all rows of r into a slice of result rows.
+// PT is inferred: orm.CollectAs[SalesReport](db.Raw(...)).
+func CollectAs[T any, PT interface {
+	*T
+	fmt.Fielder
+}](r ResultReader) ([]*T, error) {
+	var results []*T
+	err := r.ReadAllAs(
+		func() fmt.Fielder { return PT(new(T)) },
+		func(f fmt.Fielder) { results = append(results, (*T)(f.(PT))) },
+	)
+	return results, err
+}
+
+// scanResults reads rows into Fielders from new and closes rows. Columns are
+// matched by name when rows is a ColumnRows; unknown columns are discarded and
+// fields without a column keep their zero value.
+func scanResults(rows Rows, new func() fmt.Fielder, onRow func(fmt.Fielder)) error {
+	defer rows.Close()
+
+	var columns []string
+	if cr, ok := rows.(ColumnRows); ok {
+		cols, err := cr.Columns()
+		if err != nil {
+			return err
+		}
+		columns = cols
+	}
+
+	for rows.Next() {
+		f := new()
+		dest := f.Pointers()
+		if columns != nil {
+			dest = pointersByName(f, columns)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		onRow(f)
+	}
+	return rows.Err()
+}
+
+// pointersByName orders the pointers of f to match columns.
+func pointersByName(f fmt.Fielder, columns []string) []any {
+	schema, ptrs := f.Schema(), f.Pointers()
+	dest := make([]any, len(columns))
+	for i, col := range columns {
+		for j, field := range schema {
+			if field.Name == col && j < len(ptrs) {
+				dest[i] = ptrs[j]
+				break
+			}
+		}
+		if dest[i] == nil {
+			dest[i] = new(any)
+		}
+	}
+	return dest
+}
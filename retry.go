@@ -0,0 +1,46 @@
+package orm
+
+// RetryClassifier is an optional Executor extension reporting whether an error
+// is a transient failure worth retrying the whole transaction for, such as a
+// Postgres serialization failure or SQLite BUSY.
+type RetryClassifier interface {
+	Retryable(err error) bool
+}
+
+// RetryPolicy configures DB.TxRetry.
+type RetryPolicy struct {
+	MaxAttempts int       // runs of fn, counting the first; values below 1 mean 1
+	BaseDelay   int64     // milliseconds before the first retry, doubled for each next one
+	MaxDelay    int64     // upper bound of the delay in milliseconds; 0 means none
+	Options     TxOptions // passed to TxWith on every attempt
+}
+
+// TxRetry runs fn in a transaction like TxWith, and re-runs the whole
+// transaction while it fails with an error the executor classifies as
+// retryable (see RetryClassifier), up to policy.MaxAttempts runs in total,
+// waiting with exponential backoff between them. fn must therefore be safe to
+// repeat. Without a RetryClassifier executor fn runs once.
+//
+// Called on a transactional *DB, TxRetry runs fn once in a savepoint: a
+// serialization failure aborts the outer transaction, which is the one to retry.
+func (db *DB) TxRetry(policy RetryPolicy, fn func(tx *DB) error) error {
+	rc, _ := db.exec.(RetryClassifier)
+	if db.txDepth > 0 {
+		rc = nil
+	}
+
+	delay := policy.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := db.TxWith(policy.Options, fn)
+		if err == nil || rc == nil || attempt >= policy.MaxAttempts || !rc.Retryable(err) {
+			return err
+		}
+		if delay > 0 {
+			if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+				delay = policy.MaxDelay
+			}
+			db.sleep(delay)
+			delay *= 2
+		}
+	}
+}
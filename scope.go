@@ -0,0 +1,126 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// QueryScope rewrites every Query a scoped DB compiles, after the ORM has
+// built it and before the Compiler sees it. It receives all actions, DDL
+// included, and must leave queries it does not handle untouched.
+type QueryScope func(q *Query, m fmt.Model)
+
+// WithScope returns a copy of db that applies scope to every query, after any
+// scopes db already has. QB reads, writes, soft deletes and transactions
+// started from the copy are all scoped. Use Unscoped to drop the scopes.
+func (db *DB) WithScope(scope QueryScope) *DB {
+	scoped := *db
+	scoped.scopes = append(db.scopes[:len(db.scopes):len(db.scopes)], scope)
+	return &scoped
+}
+
+// WithTenant returns a copy of db scoped to one tenant. For models that have
+// column, reads, updates and deletes only match rows where column = value,
+// and creates and updates always write value into column. The value is also
+// stored in the model field before hooks and validation run, so validated
+// models need not set it. Models without the column are not affected.
+func (db *DB) WithTenant(column string, value any) *DB {
+	scoped := db.WithScope(func(q *Query, m fmt.Model) {
+		if !hasColumn(m, column) {
+			return
+		}
+		switch q.Action {
+		case ActionCreate:
+			setQueryValue(q, column, value, true)
+		case ActionUpdate:
+			setQueryValue(q, column, value, false)
+			q.Conditions = andAll(q.Conditions, Eq(column, value))
+		case ActionReadOne, ActionReadAll, ActionCount, ActionDelete:
+			q.Conditions = andAll(q.Conditions, Eq(column, value))
+		}
+	})
+	scoped.writeScopes = append(db.writeScopes[:len(db.writeScopes):len(db.writeScopes)], func(m fmt.Model) {
+		for i, f := range m.Schema() {
+			if f.Name == column {
+				setFieldValue(fieldPtr(m, i), value)
+				return
+			}
+		}
+	})
+	return scoped
+}
+
+// applyWriteScopes runs the write scopes of db on the model of a Create or
+// Update, before its hooks and validation.
+func (db *DB) applyWriteScopes(m fmt.Model) {
+	for _, scope := range db.writeScopes {
+		scope(m)
+	}
+}
+
+// Unscoped returns a copy of db without any scopes. It is the only way to run
+// queries outside the scopes of a scoped DB.
+func (db *DB) Unscoped() *DB {
+	unscoped := *db
+	unscoped.scopes = nil
+	unscoped.writeScopes = nil
+	return &unscoped
+}
+
+// compile applies the DB scopes to q and compiles it. It also returns the
+// executor that runs the plan: exec, or the one picked by its Route when exec
+// is a QueryRouter.
+func (db *DB) compile(exec Executor, q Query, m fmt.Model) (Plan, Executor, error) {
+	for _, scope := range db.scopes {
+		scope(&q, m)
+	}
+	plan, err := db.compiler.Compile(q, m)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	if r, ok := exec.(QueryRouter); ok {
+		routed, err := r.Route(q)
+		if err != nil {
+			return Plan{}, nil, err
+		}
+		exec = routed
+	}
+	return plan, exec, nil
+}
+
+// hasColumn reports whether the schema of m has a field named column.
+func hasColumn(m fmt.Model, column string) bool {
+	for _, f := range m.Schema() {
+		if f.Name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// setQueryValue sets the value written to column by q. When q does not write
+// column yet, it is appended only if add is true.
+func setQueryValue(q *Query, column string, value any, add bool) {
+	for i, c := range q.Columns {
+		if c == column && i < len(q.Values) {
+			q.Values[i] = value
+			return
+		}
+	}
+	if add {
+		q.Columns = append(q.Columns, column)
+		q.Values = append(q.Values, value)
+	}
+}
+
+// refusingExecutor is returned by RawExecutor on a scoped DB: raw queries
+// would bypass the scopes.
+type refusingExecutor struct{ exec Executor }
+
+func (r refusingExecutor) Exec(query string, args ...any) error { return ErrScoped }
+func (r refusingExecutor) QueryRow(query string, args ...any) Scanner {
+	return refusingScanner{}
+}
+func (r refusingExecutor) Query(query string, args ...any) (Rows, error) { return nil, ErrScoped }
+func (r refusingExecutor) Close() error                                  { return r.exec.Close() }
+
+type refusingScanner struct{}
+
+func (refusingScanner) Scan(dest ...any) error { return ErrScoped }
@@ -0,0 +1,376 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// QueryRouter is an optional Executor extension that picks the executor
+// running each compiled Query. The DB asks it after applying its scopes, so
+// scope conditions such as the WithTenant column can carry a shard key.
+type QueryRouter interface {
+	Executor
+	Route(q Query) (Executor, error)
+}
+
+// ShardedExecutor spreads horizontally partitioned tables over shards by the
+// value of one column, the shard key. It routes:
+//
+//   - Create to the shard of the key column in Query.Values;
+//   - reads, updates and deletes to the shards of the key values in top-level
+//     Eq/In conditions on the key column, joined by AND;
+//   - reads without a key to every shard, returning the rows of one shard
+//     after the other (Limit caps the total), and counts to the sum of the
+//     shard counts;
+//   - DDL to every shard.
+//
+// Rows from several shards are not merged in order, so reads spanning shards
+// fail with ErrUnroutable when they use OrderBy (keyset pages included),
+// GroupBy or Offset. So do writes without a key, and raw SQL run on it
+// directly. Transactions are not supported across shards; run them on one
+// shard from Shard(key).
+type ShardedExecutor struct {
+	column  string
+	shards  []Executor
+	shardOf func(key any) int
+}
+
+// NewShardedExecutor returns a ShardedExecutor keyed on column. shardOf maps
+// a key value to a shard index in [0, len(shards)); nil uses the default:
+// integers modulo the shard count, other values by a hash of their text.
+// Without shards every query fails with ErrUnroutable.
+func NewShardedExecutor(column string, shardOf func(key any) int, shards ...Executor) *ShardedExecutor {
+	if shardOf == nil {
+		n := len(shards)
+		shardOf = func(key any) int { return defaultShard(key, n) }
+	}
+	return &ShardedExecutor{column: column, shards: shards, shardOf: shardOf}
+}
+
+// Shard returns the executor holding the rows with the given key, or
+// ErrUnroutable when shardOf maps it outside the shards.
+func (s *ShardedExecutor) Shard(key any) (Executor, error) {
+	i, err := s.index(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.shards[i], nil
+}
+
+// index returns the bounds-checked shardOf(key).
+func (s *ShardedExecutor) index(key any) (int, error) {
+	if len(s.shards) == 0 {
+		return 0, ErrUnroutable
+	}
+	i := s.shardOf(key)
+	if i < 0 || i >= len(s.shards) {
+		return 0, ErrUnroutable
+	}
+	return i, nil
+}
+
+// Route implements QueryRouter.
+func (s *ShardedExecutor) Route(q Query) (Executor, error) {
+	if len(s.shards) == 0 {
+		return nil, ErrUnroutable
+	}
+	switch q.Action {
+	case ActionCreate:
+		for i, col := range q.Columns {
+			if col == s.column && i < len(q.Values) {
+				return s.Shard(q.Values[i])
+			}
+		}
+		return nil, ErrUnroutable
+	case ActionReadOne, ActionReadAll, ActionCount, ActionUpdate, ActionDelete:
+		shards := s.shards
+		if keys, ok := shardKeys(q.Conditions, s.column); ok {
+			var err error
+			if shards, err = s.shardsOf(keys); err != nil {
+				return nil, err
+			}
+		} else if q.Action == ActionUpdate || q.Action == ActionDelete {
+			return nil, ErrUnroutable
+		}
+		if len(shards) == 1 {
+			return shards[0], nil
+		}
+		if len(q.OrderBy) > 0 || len(q.GroupBy) > 0 || q.Offset > 0 {
+			return nil, ErrUnroutable
+		}
+		return fanOut{shards: shards, q: q}, nil
+	}
+	return fanOut{shards: s.shards, q: q}, nil
+}
+
+// shardsOf returns the distinct shards holding keys, in key order.
+func (s *ShardedExecutor) shardsOf(keys []any) ([]Executor, error) {
+	var shards []Executor
+	seen := make(map[int]bool, len(keys))
+	for _, k := range keys {
+		i, err := s.index(k)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[i] {
+			seen[i] = true
+			shards = append(shards, s.shards[i])
+		}
+	}
+	return shards, nil
+}
+
+// Exec refuses raw SQL, which carries no shard key.
+func (s *ShardedExecutor) Exec(query string, args ...any) error { return ErrUnroutable }
+
+// QueryRow refuses raw SQL, which carries no shard key.
+func (s *ShardedExecutor) QueryRow(query string, args ...any) Scanner {
+	return errScanner{ErrUnroutable}
+}
+
+// Query refuses raw SQL, which carries no shard key.
+func (s *ShardedExecutor) Query(query string, args ...any) (Rows, error) {
+	return nil, ErrUnroutable
+}
+
+// Close closes every shard and returns the first error.
+func (s *ShardedExecutor) Close() error {
+	var err error
+	for _, sh := range s.shards {
+		if cerr := sh.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// shardKeys returns the key values of the top-level Eq or In condition on
+// column with the fewest values. ok is false when there is none, or when
+// conds use OR logic at the top level.
+func shardKeys(conds []Condition, column string) (keys []any, ok bool) {
+	for i, c := range conds {
+		if i > 0 && c.Logic() == "OR" {
+			return nil, false
+		}
+	}
+	for _, c := range conds {
+		if c.Field() != column {
+			continue
+		}
+		var vals []any
+		switch c.Operator() {
+		case "=":
+			vals = []any{c.Value()}
+		case "IN":
+			var valid bool
+			if vals, valid = anySlice(c.Value()); !valid || len(vals) == 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		if !ok || len(vals) < len(keys) {
+			keys, ok = vals, true
+		}
+	}
+	return keys, ok
+}
+
+// anySlice converts the value of an In condition to []any.
+func anySlice(v any) ([]any, bool) {
+	var out []any
+	switch x := v.(type) {
+	case []any:
+		return x, true
+	case []string:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	case []int:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	case []int32:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	case []int64:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	case []uint:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	case []uint32:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	case []uint64:
+		for _, e := range x {
+			out = append(out, e)
+		}
+	default:
+		return nil, false
+	}
+	return out, true
+}
+
+// defaultShard maps integer keys modulo n and other keys by the FNV-1a hash
+// of their text, so 7 and int64(7) land on the same shard.
+func defaultShard(key any, n int) int {
+	if n == 0 {
+		return -1
+	}
+	if i, ok := intValue(key); ok {
+		if i < 0 {
+			i = -i
+		}
+		return int(uint64(i) % uint64(n))
+	}
+	s, ok := key.(string)
+	if !ok {
+		s = fmt.Convert(key).String()
+	}
+	return int(fnv1a(fnvOffset, s) % uint64(n))
+}
+
+// fanOut runs one compiled query on several shards.
+type fanOut struct {
+	shards []Executor
+	q      Query
+}
+
+// Exec runs the statement on every shard, stopping at the first error.
+func (f fanOut) Exec(query string, args ...any) error {
+	for _, sh := range f.shards {
+		if err := sh.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecResult sums the rows affected on every shard; -1 when a shard cannot
+// report it. LastInsertID is unknown.
+func (f fanOut) ExecResult(query string, args ...any) (Result, error) {
+	total := Result{LastInsertID: -1}
+	for _, sh := range f.shards {
+		res, err := execResult(sh, Plan{Query: query, Args: args})
+		if err != nil {
+			return Result{}, err
+		}
+		if res.RowsAffected < 0 || total.RowsAffected < 0 {
+			total.RowsAffected = -1
+		} else {
+			total.RowsAffected += res.RowsAffected
+		}
+	}
+	return total, nil
+}
+
+// QueryRow sums the shard counts for ActionCount; otherwise (an unordered
+// ReadOne) it scans the first row found on any shard, or returns ErrNotFound.
+func (f fanOut) QueryRow(query string, args ...any) Scanner {
+	return fanOutRow{f: f, query: query, args: args}
+}
+
+// Query returns the rows of every shard, one shard after the other.
+func (f fanOut) Query(query string, args ...any) (Rows, error) {
+	m := &multiRows{limit: f.q.Limit}
+	for _, sh := range f.shards {
+		rows, err := sh.Query(query, args...)
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		m.rows = append(m.rows, rows)
+	}
+	return m, nil
+}
+
+func (f fanOut) Close() error { return nil }
+
+type fanOutRow struct {
+	f     fanOut
+	query string
+	args  []any
+}
+
+func (r fanOutRow) Scan(dest ...any) error {
+	if r.f.q.Action == ActionCount {
+		var total int64
+		for _, sh := range r.f.shards {
+			var n int64
+			if err := sh.QueryRow(r.query, r.args...).Scan(&n); err != nil {
+				return err
+			}
+			total += n
+		}
+		if len(dest) != 1 || !setIntPtr(dest[0], total) {
+			return fmt.Err("count", "needs", "one", "integer", "destination")
+		}
+		return nil
+	}
+
+	for _, sh := range r.f.shards {
+		rows, err := sh.Query(r.query, r.args...)
+		if err != nil {
+			return err
+		}
+		if rows.Next() {
+			err = rows.Scan(dest...)
+			rows.Close()
+			return err
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return ErrNotFound
+}
+
+// multiRows reads several Rows in sequence, stopping after limit rows when
+// limit is positive.
+type multiRows struct {
+	rows  []Rows
+	i     int
+	limit int
+	n     int
+	err   error
+}
+
+func (m *multiRows) Next() bool {
+	if m.limit > 0 && m.n >= m.limit {
+		return false
+	}
+	for m.i < len(m.rows) {
+		if m.rows[m.i].Next() {
+			m.n++
+			return true
+		}
+		if err := m.rows[m.i].Err(); err != nil {
+			m.err = err
+			return false
+		}
+		m.i++
+	}
+	return false
+}
+
+func (m *multiRows) Scan(dest ...any) error { return m.rows[m.i].Scan(dest...) }
+
+func (m *multiRows) Err() error { return m.err }
+
+func (m *multiRows) Close() error {
+	var err error
+	for _, r := range m.rows {
+		if cerr := r.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type errScanner struct{ err error }
+
+func (s errScanner) Scan(dest ...any) error { return s.err }
@@ -0,0 +1,120 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// Soft delete: models with a db:"deleted_at" int64 field are never removed by
+// DB.Delete. The column is set to the current unix time instead, and QB reads
+// skip rows where it is non-zero unless WithDeleted or OnlyDeleted is used.
+
+// softDelete marks the live rows matching conds as deleted at db.now() and
+// stores the timestamp in the model field i.
+func (db *DB) softDelete(m fmt.Model, i int, conds []Condition) (Result, error) {
+	col := m.Schema()[i].Name
+	ts := db.now()
+	res, err := db.setColumn(m, col, ts, andAll(conds, Eq(col, int64(0))))
+	if err == nil {
+		setIntPtr(fieldPtr(m, i), ts)
+	}
+	return res, err
+}
+
+// Restore clears the soft-delete marker of the deleted rows matching the
+// conditions. Models without a db:"deleted_at" field return an error.
+func (db *DB) Restore(m fmt.Model, cond Condition, rest ...Condition) error {
+	if err := writable(m); err != nil {
+		return err
+	}
+	i := softDeleteIndex(m)
+	if i < 0 {
+		return fmt.Err("model", m.ModelName(), "has no deleted_at field")
+	}
+	col := m.Schema()[i].Name
+	_, err := db.setColumn(m, col, int64(0), andAll(append([]Condition{cond}, rest...), Gt(col, int64(0))))
+	if err == nil {
+		setIntPtr(fieldPtr(m, i), 0)
+	}
+	return err
+}
+
+// ForceDelete removes the rows matching the conditions even when the model
+// uses soft delete. Hooks and validation run as for Delete.
+func (db *DB) ForceDelete(m fmt.Model, cond Condition, rest ...Condition) error {
+	if err := writable(m); err != nil {
+		return err
+	}
+	if err := db.hook(hookBeforeDelete, m); err != nil {
+		return err
+	}
+	if err := db.validate('d', m); err != nil {
+		return err
+	}
+	if _, err := db.delete(m, append([]Condition{cond}, rest...)); err != nil {
+		return err
+	}
+	return db.hook(hookAfterDelete, m)
+}
+
+// setColumn updates a single column of the rows matching conds.
+func (db *DB) setColumn(m fmt.Model, col string, val any, conds []Condition) (Result, error) {
+	if err := validateQuery(ActionUpdate, m); err != nil {
+		return Result{}, err
+	}
+	q := Query{
+		Action:     ActionUpdate,
+		Table:      m.ModelName(),
+		Columns:    []string{col},
+		Values:     []any{val},
+		Conditions: conds,
+	}
+	plan, exec, err := db.compile(db.exec, q, m)
+	if err != nil {
+		return Result{}, err
+	}
+	return execResult(exec, plan)
+}
+
+// fieldPtr returns the pointer of field i of m, or nil.
+func fieldPtr(m fmt.Model, i int) any {
+	ptrs := m.Pointers()
+	if i < len(ptrs) {
+		return ptrs[i]
+	}
+	return nil
+}
+
+// deletedFilter selects which rows QB reads return for soft-delete models.
+type deletedFilter uint8
+
+const (
+	liveOnly deletedFilter = iota
+	withDeleted
+	onlyDeleted
+)
+
+// WithDeleted includes soft-deleted rows in the results.
+func (qb *QB) WithDeleted() *QB {
+	qb.deleted = withDeleted
+	return qb
+}
+
+// OnlyDeleted returns soft-deleted rows only.
+func (qb *QB) OnlyDeleted() *QB {
+	qb.deleted = onlyDeleted
+	return qb
+}
+
+// softDeleteFilter returns conds plus the soft-delete filter of the query.
+func (qb *QB) softDeleteFilter(conds []Condition) []Condition {
+	i := softDeleteIndex(qb.model)
+	if i < 0 {
+		return conds
+	}
+	col := qb.model.Schema()[i].Name
+	switch qb.deleted {
+	case withDeleted:
+		return conds
+	case onlyDeleted:
+		return andAll(conds, Gt(col, int64(0)))
+	}
+	return andAll(conds, Eq(col, int64(0)))
+}
@@ -0,0 +1,26 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// tableModel is a pointer-less fmt.Model built from a table name and fields.
+type tableModel struct {
+	name   string
+	schema []fmt.Field
+	ext    []FieldExt
+}
+
+func (t tableModel) ModelName() string     { return t.name }
+func (t tableModel) Schema() []fmt.Field   { return t.schema }
+func (t tableModel) Pointers() []any       { return nil }
+func (t tableModel) SchemaExt() []FieldExt { return t.ext }
+
+// TableModel returns a fmt.Model describing a table by name and fields only.
+// Migration files generated by `ormc migrate new` use it to issue DDL without
+// importing the application model packages. It cannot be used for writes.
+func TableModel(name string, fields ...FieldExt) fmt.Model {
+	schema := make([]fmt.Field, len(fields))
+	for i, f := range fields {
+		schema[i] = f.Field
+	}
+	return tableModel{name: name, schema: schema, ext: fields}
+}
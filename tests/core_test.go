@@ -3,6 +3,7 @@ package tests
 import (
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/tinywasm/fmt"
@@ -182,9 +183,10 @@ func RunCoreTests(t *testing.T) {
 		}
 	})
 
-	// 6. Test Validation (Verify db.Create no longer calls Validate)
-	t.Run("Create No Longer Calls Validate", func(t *testing.T) {
-		db := orm.New(&MockExecutor{}, &MockCompiler{})
+	// 6. Test Validation (db.Create calls Validate('c'))
+	t.Run("Create Calls Validate", func(t *testing.T) {
+		mockExec := &MockExecutor{}
+		db := orm.New(mockExec, &MockCompiler{})
 		model := &MockModel{
 			Table:    "user",
 			Sch:      []fmt.Field{{Name: "col1"}},
@@ -193,13 +195,20 @@ func RunCoreTests(t *testing.T) {
 		}
 
 		err := db.Create(model)
-		if err != nil {
-			t.Errorf("Expected no validation error from db.Create, got %v", err)
+		if !errors.Is(err, orm.ErrValidation) || !strings.Contains(err.Error(), "custom validation error") {
+			t.Errorf("Expected wrapped ErrValidation from db.Create, got %v", err)
+		}
+		if len(mockExec.ExecutedQueries) != 0 {
+			t.Errorf("Expected no queries after failed validation, got %v", mockExec.ExecutedQueries)
+		}
+		var ve *orm.ValidationError
+		if !errors.As(err, &ve) || ve.Err != model.ValidErr {
+			t.Errorf("Expected ValidationError holding the model error, got %v", err)
 		}
 	})
 
-	// 7. Test Validation (Verify db.Update no longer calls Validate)
-	t.Run("Update No Longer Calls Validate", func(t *testing.T) {
+	// 7. Test Validation (db.Update and db.Delete call Validate)
+	t.Run("Update and Delete Call Validate", func(t *testing.T) {
 		db := orm.New(&MockExecutor{}, &MockCompiler{})
 		model := &MockModel{
 			Table:    "user",
@@ -208,10 +217,37 @@ func RunCoreTests(t *testing.T) {
 			ValidErr: errors.New("custom validation error"),
 		}
 
-		err := db.Update(model, orm.Eq("id", 1))
-		if err != nil {
+		if err := db.Update(model, orm.Eq("id", 1)); !errors.Is(err, orm.ErrValidation) {
+			t.Errorf("Expected ErrValidation from db.Update, got %v", err)
+		}
+		if err := db.Delete(model, orm.Eq("id", 1)); !errors.Is(err, orm.ErrValidation) {
+			t.Errorf("Expected ErrValidation from db.Delete, got %v", err)
+		}
+	})
+
+	// Test WithoutValidation option
+	t.Run("WithoutValidation", func(t *testing.T) {
+		db := orm.New(&MockExecutor{}, &MockCompiler{}, orm.WithoutValidation())
+		model := &MockModel{
+			Table:    "user",
+			Sch:      []fmt.Field{{Name: "col1"}},
+			Vals:     []any{1},
+			ValidErr: errors.New("custom validation error"),
+		}
+
+		if err := db.Create(model); err != nil {
+			t.Errorf("Expected no validation error from db.Create, got %v", err)
+		}
+		if err := db.Update(model, orm.Eq("id", 1)); err != nil {
 			t.Errorf("Expected no validation error from db.Update, got %v", err)
 		}
+
+		// The option carries over to the transactional DB
+		db = orm.New(&MockTxExecutor{}, &MockCompiler{}, orm.WithoutValidation())
+		err := db.Tx(func(tx *orm.DB) error { return tx.Create(model) })
+		if err != nil {
+			t.Errorf("Expected no validation error inside Tx, got %v", err)
+		}
 	})
 
 	// Test Validation Error (Delete)
@@ -321,6 +357,142 @@ func RunCoreTests(t *testing.T) {
 		}
 	})
 
+	// Test rollback failures are reported with the original error
+	t.Run("Transaction Rollback Error", func(t *testing.T) {
+		rbErr := errors.New("connection lost")
+		db := orm.New(&MockTxExecutor{Bound: &MockTxBoundExecutor{RollbackErr: rbErr}}, &MockCompiler{})
+
+		fnErr := errors.New("oops")
+		err := db.Tx(func(tx *orm.DB) error { return fnErr })
+		if !errors.Is(err, fnErr) || !errors.Is(err, rbErr) {
+			t.Errorf("Expected both errors joined, got %v", err)
+		}
+		var rollbackErr *orm.RollbackError
+		if !errors.As(err, &rollbackErr) || rollbackErr.RollbackErr != rbErr {
+			t.Errorf("Expected *orm.RollbackError, got %T", err)
+		}
+	})
+
+	// Test a panic in fn rolls back and is re-raised
+	t.Run("Transaction Panic", func(t *testing.T) {
+		bound := &MockTxBoundExecutor{}
+		db := orm.New(&MockTxExecutor{Bound: bound}, &MockCompiler{})
+
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("Expected panic to be re-raised, got %v", p)
+			}
+			if !bound.RollbackCalled || bound.CommitCalled {
+				t.Error("Expected Rollback and no Commit after panic")
+			}
+		}()
+		db.Tx(func(tx *orm.DB) error { panic("boom") })
+	})
+
+	// Test TxWith passes options to executors that honor them
+	t.Run("TxWith Options", func(t *testing.T) {
+		optExec := &MockTxOptionsExecutor{}
+		db := orm.New(optExec, &MockCompiler{})
+		opts := orm.TxOptions{Isolation: orm.LevelSerializable, ReadOnly: true}
+		if err := db.TxWith(opts, func(*orm.DB) error { return nil }); err != nil {
+			t.Fatalf("TxWith failed: %v", err)
+		}
+		if optExec.Opts == nil || *optExec.Opts != opts || !optExec.Bound.CommitCalled {
+			t.Errorf("Expected options passed to BeginTxWith, got %v", optExec.Opts)
+		}
+
+		plain := orm.New(&MockTxExecutor{}, &MockCompiler{})
+		if err := plain.TxWith(opts, func(*orm.DB) error { return nil }); !errors.Is(err, orm.ErrNoTxSupport) {
+			t.Errorf("Expected ErrNoTxSupport without TxOptionsExecutor, got %v", err)
+		}
+		if err := plain.TxWith(orm.TxOptions{}, func(*orm.DB) error { return nil }); err != nil {
+			t.Errorf("Expected default options to use BeginTx, got %v", err)
+		}
+	})
+
+	// Test TxRetry re-runs transactions failing with retryable errors
+	t.Run("TxRetry", func(t *testing.T) {
+		busy := &MockBusyExecutor{Busy: 3}
+		var waits []int64
+		db := orm.New(busy, &MockCompiler{}, orm.WithSleep(func(ms int64) { waits = append(waits, ms) }))
+		policy := orm.RetryPolicy{MaxAttempts: 5, BaseDelay: 10, MaxDelay: 25}
+
+		runs := 0
+		err := db.TxRetry(policy, func(*orm.DB) error {
+			runs++
+			return nil
+		})
+		if err != nil || runs != 4 || busy.Begun != 4 {
+			t.Errorf("Expected success on the 4th run, got %d runs, %d begun, %v", runs, busy.Begun, err)
+		}
+		if !reflect.DeepEqual(waits, []int64{10, 20, 25}) {
+			t.Errorf("Expected capped exponential backoff, got %v", waits)
+		}
+
+		// Gives up after MaxAttempts
+		busy = &MockBusyExecutor{Busy: 10}
+		db = orm.New(busy, &MockCompiler{}, orm.WithSleep(func(int64) {}))
+		if err := db.TxRetry(policy, func(*orm.DB) error { return nil }); !errors.Is(err, errBusy) || busy.Begun != 5 {
+			t.Errorf("Expected errBusy after 5 attempts, got %v after %d", err, busy.Begun)
+		}
+
+		// Other errors are not retried
+		busy = &MockBusyExecutor{}
+		db = orm.New(busy, &MockCompiler{})
+		fnErr := errors.New("not transient")
+		if err := db.TxRetry(policy, func(*orm.DB) error { return fnErr }); err != fnErr || busy.Begun != 1 {
+			t.Errorf("Expected a single attempt, got %v after %d", err, busy.Begun)
+		}
+
+		// Executors without a RetryClassifier run fn once
+		plain := orm.New(&MockTxExecutor{Bound: &MockTxBoundExecutor{CommitErr: errBusy}}, &MockCompiler{})
+		runs = 0
+		plain.TxRetry(policy, func(*orm.DB) error { runs++; return nil })
+		if runs != 1 {
+			t.Errorf("Expected one run without RetryClassifier, got %d", runs)
+		}
+	})
+
+	// Test nested transactions through savepoints
+	t.Run("Nested Tx Savepoints", func(t *testing.T) {
+		bound := &MockSavepointExecutor{}
+		db := orm.New(&MockSavepointTxExecutor{Bound: bound}, &MockCompiler{})
+
+		innerErr := errors.New("inner failed")
+		var gotInner error
+		err := db.Tx(func(tx *orm.DB) error {
+			if err := tx.Tx(func(sp *orm.DB) error {
+				return sp.Tx(func(*orm.DB) error { return nil })
+			}); err != nil {
+				return err
+			}
+			gotInner = tx.Tx(func(*orm.DB) error { return innerErr })
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Tx failed: %v", err)
+		}
+		if gotInner != innerErr {
+			t.Errorf("Expected inner error returned to caller, got %v", gotInner)
+		}
+		expected := "savepoint sp_1,savepoint sp_2,release sp_2,release sp_1,savepoint sp_1,rollback sp_1"
+		if strings.Join(bound.Log, ",") != expected {
+			t.Errorf("Expected %s, got %v", expected, bound.Log)
+		}
+		if !bound.CommitCalled || bound.RollbackCalled {
+			t.Error("Expected the outer transaction to commit")
+		}
+
+		// Without savepoint support nested Tx is refused
+		plain := orm.New(&MockTxExecutor{}, &MockCompiler{})
+		err = plain.Tx(func(tx *orm.DB) error {
+			return tx.Tx(func(*orm.DB) error { return nil })
+		})
+		if !errors.Is(err, orm.ErrNoTxSupport) {
+			t.Errorf("Expected ErrNoTxSupport for nested Tx, got %v", err)
+		}
+	})
+
 	// 13. Test Condition Helpers
 	t.Run("Condition Helpers", func(t *testing.T) {
 		tests := []struct {
@@ -472,6 +644,1135 @@ func RunCoreTests(t *testing.T) {
 		}
 	})
 
+	// Test Column DDL Actions
+	t.Run("Column DDL", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockExecutor{}
+		db := orm.New(mockExec, mockCompiler)
+
+		model := orm.TableModel("user", orm.FieldExt{Field: fmt.Field{Name: "email", Type: fmt.FieldText}})
+
+		steps := []struct {
+			action orm.Action
+			run    func() error
+		}{
+			{orm.ActionAddColumn, func() error { return db.AddColumn(model, "email") }},
+			{orm.ActionAlterColumn, func() error { return db.AlterColumn(model, "email") }},
+			{orm.ActionDropColumn, func() error { return db.DropColumn(model, "email") }},
+		}
+		for _, s := range steps {
+			if err := s.run(); err != nil {
+				t.Fatalf("column DDL failed: %v", err)
+			}
+			if mockCompiler.LastQuery.Action != s.action {
+				t.Errorf("Expected action %v, got %v", s.action, mockCompiler.LastQuery.Action)
+			}
+			if mockCompiler.LastQuery.Table != "user" {
+				t.Errorf("Expected table 'user', got '%s'", mockCompiler.LastQuery.Table)
+			}
+			if len(mockCompiler.LastQuery.Columns) != 1 || mockCompiler.LastQuery.Columns[0] != "email" {
+				t.Errorf("Expected column [email], got %v", mockCompiler.LastQuery.Columns)
+			}
+		}
+		if len(mockExec.ExecutedQueries) != 3 {
+			t.Errorf("Expected 3 executed queries, got %d", len(mockExec.ExecutedQueries))
+		}
+
+		// TableModel has no pointers and cannot be written
+		if err := db.Create(model); err == nil {
+			t.Error("Expected Create on TableModel to fail")
+		}
+	})
+
+	// Test Create skips zero-valued fields that have a DB default
+	t.Run("Create Skips Defaults", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		db := orm.New(&MockExecutor{}, mockCompiler)
+
+		model := &MockTask{Title: "write docs"}
+		if err := db.Create(model); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if !reflect.DeepEqual(mockCompiler.LastQuery.Columns, []string{"title"}) {
+			t.Errorf("Expected only [title], got %v", mockCompiler.LastQuery.Columns)
+		}
+
+		// Non-zero values are always written
+		model.ID, model.Status, model.Priority = 7, "done", 5
+		if err := db.Create(model); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if len(mockCompiler.LastQuery.Columns) != 4 {
+			t.Errorf("Expected 4 columns, got %v", mockCompiler.LastQuery.Columns)
+		}
+	})
+
+	// Test autoincrement PK write-back after Create
+	t.Run("Create Writes Back AutoInc ID", func(t *testing.T) {
+		// RETURNING: the compiler marks the plan and the DB scans the row
+		mockCompiler := &MockCompiler{ReturnPlan: orm.Plan{Returning: true}}
+		mockExec := &MockExecutor{ReturnQueryRow: &MockScanner{Vals: []any{int64(41)}}}
+		db := orm.New(mockExec, mockCompiler)
+
+		task := &MockTask{Title: "a"}
+		if err := db.Create(task); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if !reflect.DeepEqual(mockCompiler.LastQuery.Returning, []string{"id"}) {
+			t.Errorf("Expected Returning [id], got %v", mockCompiler.LastQuery.Returning)
+		}
+		if task.ID != 41 {
+			t.Errorf("Expected ID 41 from RETURNING row, got %d", task.ID)
+		}
+
+		// LastInsertID: plain plan on a ResultExecutor
+		resExec := &MockResultExecutor{Result: orm.Result{RowsAffected: 1, LastInsertID: 42}}
+		db = orm.New(resExec, &MockCompiler{})
+		task = &MockTask{Title: "b"}
+		if err := db.Create(task); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if task.ID != 42 {
+			t.Errorf("Expected ID 42 from LastInsertID, got %d", task.ID)
+		}
+
+		// Non-zero PK: no Returning requested, plain Exec
+		mockCompiler = &MockCompiler{}
+		mockExec = &MockExecutor{}
+		db = orm.New(mockExec, mockCompiler)
+		task = &MockTask{ID: 5, Title: "c"}
+		if err := db.Create(task); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if len(mockCompiler.LastQuery.Returning) != 0 {
+			t.Errorf("Expected no Returning, got %v", mockCompiler.LastQuery.Returning)
+		}
+		if len(mockExec.ExecutedQueries) != 1 || task.ID != 5 {
+			t.Errorf("Expected one Exec and ID unchanged, got %d queries, ID %d", len(mockExec.ExecutedQueries), task.ID)
+		}
+
+		// ResultExecutor errors are returned
+		resExec = &MockResultExecutor{MockExecutor: MockExecutor{ReturnExecErr: errors.New("exec err")}}
+		db = orm.New(resExec, &MockCompiler{})
+		if err := db.Create(&MockTask{}); err == nil || err.Error() != "exec err" {
+			t.Errorf("Expected exec err, got %v", err)
+		}
+	})
+
+	// Test optimistic locking through db:"version"
+	t.Run("Update Optimistic Lock", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		resExec := &MockResultExecutor{Result: orm.Result{RowsAffected: 1}}
+		db := orm.New(resExec, mockCompiler)
+
+		doc := &MockDoc{ID: 1, Body: "a", Version: 3}
+		if err := db.Update(doc, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		q := mockCompiler.LastQuery
+		if len(q.Conditions) != 2 || q.Conditions[1].Field() != "version" || q.Conditions[1].Value() != int64(3) {
+			t.Errorf("Expected version = 3 guard, got %+v", q.Conditions)
+		}
+		if q.Values[2] != int64(4) {
+			t.Errorf("Expected version value 4, got %v", q.Values[2])
+		}
+		if doc.Version != 4 {
+			t.Errorf("Expected model version 4, got %d", doc.Version)
+		}
+
+		// Zero rows affected: stale, model untouched
+		resExec.Result.RowsAffected = 0
+		if err := db.Update(doc, orm.Eq("id", 1)); !errors.Is(err, orm.ErrStaleObject) {
+			t.Errorf("Expected ErrStaleObject, got %v", err)
+		}
+		if doc.Version != 4 {
+			t.Errorf("Expected model version to stay 4, got %d", doc.Version)
+		}
+
+		// OR conditions are grouped so the guard always applies
+		resExec.Result.RowsAffected = 1
+		if err := db.Update(doc, orm.Eq("id", 1), orm.Or(orm.Eq("id", 2))); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		q = mockCompiler.LastQuery
+		if len(q.Conditions) != 2 || q.Conditions[0].Operator() != "GROUP" || len(q.Conditions[0].Group()) != 2 {
+			t.Errorf("Expected grouped conditions plus guard, got %+v", q.Conditions)
+		}
+		if q.Conditions[1].Logic() != "AND" {
+			t.Errorf("Expected AND guard, got %s", q.Conditions[1].Logic())
+		}
+
+		// Plain Executor: guard applied, staleness not detectable
+		mockExec := &MockExecutor{}
+		db = orm.New(mockExec, &MockCompiler{})
+		doc = &MockDoc{ID: 1, Version: 0}
+		if err := db.Update(doc, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if doc.Version != 1 || len(mockExec.ExecutedQueries) != 1 {
+			t.Errorf("Expected version 1 after one Exec, got %d (%d queries)", doc.Version, len(mockExec.ExecutedQueries))
+		}
+	})
+
+	// Test write results from UpdateN/DeleteN
+	t.Run("UpdateN and DeleteN", func(t *testing.T) {
+		model := &MockModel{Table: "t", Sch: []fmt.Field{{Name: "a"}}, Vals: []any{1}}
+
+		resExec := &MockResultExecutor{Result: orm.Result{RowsAffected: 2, LastInsertID: 0}}
+		db := orm.New(resExec, &MockCompiler{})
+		res, err := db.UpdateN(model, orm.Eq("a", 1))
+		if err != nil || res.RowsAffected != 2 {
+			t.Errorf("Expected 2 rows affected, got %+v, %v", res, err)
+		}
+		resExec.Result.RowsAffected = 0
+		res, err = db.DeleteN(model, orm.Eq("a", 1))
+		if err != nil || res.RowsAffected != 0 {
+			t.Errorf("Expected 0 rows affected, got %+v, %v", res, err)
+		}
+
+		// Plain Executor: counters unknown
+		mockExec := &MockExecutor{}
+		db = orm.New(mockExec, &MockCompiler{})
+		res, err = db.UpdateN(model, orm.Eq("a", 1))
+		if err != nil || res.RowsAffected != -1 || res.LastInsertID != -1 {
+			t.Errorf("Expected unknown result, got %+v, %v", res, err)
+		}
+		res, err = db.DeleteN(model, orm.Eq("a", 1))
+		if err != nil || res.RowsAffected != -1 {
+			t.Errorf("Expected unknown result, got %+v, %v", res, err)
+		}
+		if len(mockExec.ExecutedQueries) != 2 {
+			t.Errorf("Expected 2 Exec calls, got %d", len(mockExec.ExecutedQueries))
+		}
+
+		// Errors are propagated
+		if _, err := db.DeleteN(&MockModel{}, orm.Eq("a", 1)); !errors.Is(err, orm.ErrEmptyTable) {
+			t.Errorf("Expected ErrEmptyTable, got %v", err)
+		}
+		resExec = &MockResultExecutor{MockExecutor: MockExecutor{ReturnExecErr: errors.New("exec err")}}
+		db = orm.New(resExec, &MockCompiler{})
+		if _, err := db.UpdateN(model, orm.Eq("a", 1)); err == nil || err.Error() != "exec err" {
+			t.Errorf("Expected exec err, got %v", err)
+		}
+	})
+
+	// Test lifecycle hooks
+	t.Run("Hooks", func(t *testing.T) {
+		mockExec := &MockExecutor{}
+		db := orm.New(mockExec, &MockCompiler{})
+
+		task := &MockHookTask{}
+		task.ID = 1
+		if err := db.Create(task); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := db.Update(task, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if _, err := db.DeleteN(task, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("DeleteN failed: %v", err)
+		}
+		if err := db.Query(task).ReadOne(); err != nil {
+			t.Fatalf("ReadOne failed: %v", err)
+		}
+		expected := []string{"BeforeCreate", "AfterCreate", "BeforeUpdate", "AfterUpdate", "BeforeDelete", "AfterDelete", "AfterRead"}
+		if !reflect.DeepEqual(task.Calls, expected) {
+			t.Errorf("Expected hooks %v, got %v", expected, task.Calls)
+		}
+		if task.SeenDB != db {
+			t.Error("Expected hooks to receive the DB")
+		}
+
+		// Before-hook errors abort the write
+		mockExec.ExecutedQueries = nil
+		task = &MockHookTask{Fail: "BeforeCreate"}
+		if err := db.Create(task); err == nil || err.Error() != "BeforeCreate failed" {
+			t.Errorf("Expected BeforeCreate error, got %v", err)
+		}
+		task.Fail = "BeforeUpdate"
+		if err := db.Update(task, orm.Eq("id", 1)); err == nil {
+			t.Error("Expected BeforeUpdate error")
+		}
+		task.Fail = "BeforeDelete"
+		if err := db.Delete(task, orm.Eq("id", 1)); err == nil {
+			t.Error("Expected BeforeDelete error")
+		}
+		if len(mockExec.ExecutedQueries) != 0 {
+			t.Errorf("Expected no queries after failed before-hooks, got %v", mockExec.ExecutedQueries)
+		}
+
+		// After-hook errors are returned once the write ran
+		task.Fail = "AfterUpdate"
+		if err := db.Update(task, orm.Eq("id", 1)); err == nil || len(mockExec.ExecutedQueries) != 1 {
+			t.Errorf("Expected AfterUpdate error after one query, got %v", err)
+		}
+
+		// AfterRead runs per row and stops ReadAll on error
+		db = orm.New(&MockExecutor{ReturnQueryRows: &MockRows{Count: 3}}, &MockCompiler{})
+		var read []*MockHookTask
+		err := db.Query(&MockHookTask{}).ReadAll(
+			func() fmt.Model { return &MockHookTask{Fail: "AfterRead"} },
+			func(m fmt.Model) { read = append(read, m.(*MockHookTask)) },
+		)
+		if err == nil || len(read) != 0 {
+			t.Errorf("Expected AfterRead error before onRow, got %v, %d rows", err, len(read))
+		}
+
+		// Inside Tx hooks receive the transactional DB
+		db = orm.New(&MockTxExecutor{}, &MockCompiler{})
+		task = &MockHookTask{}
+		var txDB *orm.DB
+		err = db.Tx(func(tx *orm.DB) error {
+			txDB = tx
+			return tx.Create(task)
+		})
+		if err != nil {
+			t.Fatalf("Tx failed: %v", err)
+		}
+		if task.SeenDB != txDB || txDB == db {
+			t.Error("Expected hooks to receive the transactional DB")
+		}
+	})
+
+	// Test soft delete through db:"deleted_at"
+	t.Run("Soft Delete", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockExecutor{}
+		db := orm.New(mockExec, mockCompiler)
+
+		note := &MockNote{ID: 1, Text: "a"}
+		if err := db.Delete(note, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		q := mockCompiler.LastQuery
+		if q.Action != orm.ActionUpdate || !reflect.DeepEqual(q.Columns, []string{"deleted_at"}) {
+			t.Errorf("Expected update of deleted_at, got %v %v", q.Action, q.Columns)
+		}
+		if len(q.Conditions) != 2 || q.Conditions[1].Field() != "deleted_at" || q.Conditions[1].Value() != int64(0) {
+			t.Errorf("Expected live-row guard, got %+v", q.Conditions)
+		}
+		if note.DeletedAt <= 0 || q.Values[0] != note.DeletedAt {
+			t.Errorf("Expected deleted_at timestamp written to query and model, got %v / %d", q.Values, note.DeletedAt)
+		}
+
+		// Update neither writes the marker nor matches deleted rows
+		if err := db.Update(&MockNote{ID: 1, Text: "x"}, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		uq := mockCompiler.LastQuery
+		if !reflect.DeepEqual(uq.Columns, []string{"id", "text"}) || !reflect.DeepEqual(uq.Values, []any{int64(1), "x"}) {
+			t.Errorf("Expected update without deleted_at, got %v %v", uq.Columns, uq.Values)
+		}
+		if len(uq.Conditions) != 2 || uq.Conditions[1].Field() != "deleted_at" || uq.Conditions[1].Operator() != "=" || uq.Conditions[1].Value() != int64(0) {
+			t.Errorf("Expected live-row guard on update, got %+v", uq.Conditions)
+		}
+
+		if err := db.Restore(note, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		q = mockCompiler.LastQuery
+		if q.Values[0] != int64(0) || q.Conditions[1].Operator() != ">" || note.DeletedAt != 0 {
+			t.Errorf("Expected restore to clear deleted rows, got %+v, model %d", q, note.DeletedAt)
+		}
+		if err := db.Restore(&MockTask{}, orm.Eq("id", 1)); err == nil {
+			t.Error("Expected Restore error for model without deleted_at")
+		}
+
+		if err := db.ForceDelete(note, orm.Eq("id", 1)); err != nil {
+			t.Fatalf("ForceDelete failed: %v", err)
+		}
+		if mockCompiler.LastQuery.Action != orm.ActionDelete {
+			t.Errorf("Expected ActionDelete from ForceDelete, got %v", mockCompiler.LastQuery.Action)
+		}
+
+		// Reads filter deleted rows unless asked otherwise
+		if err := db.Query(&MockNote{}).Where("text").Eq("a").ReadOne(); err != nil {
+			t.Fatalf("ReadOne failed: %v", err)
+		}
+		conds := mockCompiler.LastQuery.Conditions
+		if len(conds) != 2 || conds[1].Field() != "deleted_at" || conds[1].Operator() != "=" {
+			t.Errorf("Expected deleted_at = 0 filter, got %+v", conds)
+		}
+		noop := func(fmt.Model) {}
+		newNote := func() fmt.Model { return &MockNote{} }
+		if err := db.Query(&MockNote{}).WithDeleted().ReadAll(newNote, noop); err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		if len(mockCompiler.LastQuery.Conditions) != 0 {
+			t.Errorf("Expected no filter WithDeleted, got %+v", mockCompiler.LastQuery.Conditions)
+		}
+		if err := db.Query(&MockNote{}).OnlyDeleted().ReadAll(newNote, noop); err != nil {
+			t.Fatalf("ReadAll failed: %v", err)
+		}
+		conds = mockCompiler.LastQuery.Conditions
+		if len(conds) != 1 || conds[0].Operator() != ">" {
+			t.Errorf("Expected deleted_at > 0 filter, got %+v", conds)
+		}
+
+		// OR conditions are grouped under the filter
+		db.Query(&MockNote{}).Where("id").Eq(1).Or().Where("id").Eq(2).ReadOne()
+		conds = mockCompiler.LastQuery.Conditions
+		if len(conds) != 2 || conds[0].Operator() != "GROUP" {
+			t.Errorf("Expected grouped conditions, got %+v", conds)
+		}
+
+		// Models without deleted_at are deleted for real
+		db.Delete(&MockTask{}, orm.Eq("id", 1))
+		if mockCompiler.LastQuery.Action != orm.ActionDelete {
+			t.Errorf("Expected ActionDelete, got %v", mockCompiler.LastQuery.Action)
+		}
+	})
+
+	// Test db:"created"/db:"updated" timestamps with a pinned clock
+	t.Run("Timestamps", func(t *testing.T) {
+		now := int64(1000)
+		mockCompiler := &MockCompiler{}
+		db := orm.New(&MockExecutor{}, mockCompiler, orm.WithClock(func() int64 { return now }))
+
+		post := &MockPost{ID: 1, Title: "a"}
+		if err := db.Create(post); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if post.CreatedAt != 1000 || post.UpdatedAt != 1000 {
+			t.Errorf("Expected both timestamps 1000, got %d/%d", post.CreatedAt, post.UpdatedAt)
+		}
+		if !reflect.DeepEqual(mockCompiler.LastQuery.Values, []any{int64(1), "a", int64(1000), int64(1000)}) {
+			t.Errorf("Unexpected insert values %v", mockCompiler.LastQuery.Values)
+		}
+
+		// A preset created timestamp is kept
+		post = &MockPost{ID: 2, CreatedAt: 5}
+		db.Create(post)
+		if post.CreatedAt != 5 || post.UpdatedAt != 1000 {
+			t.Errorf("Expected created 5 and updated 1000, got %d/%d", post.CreatedAt, post.UpdatedAt)
+		}
+
+		// Update refreshes updated and never writes created
+		now = 2000
+		if err := db.Update(post, orm.Eq("id", 2)); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		q := mockCompiler.LastQuery
+		if !reflect.DeepEqual(q.Columns, []string{"id", "title", "updated_at"}) {
+			t.Errorf("Expected created_at left out, got %v", q.Columns)
+		}
+		if q.Values[2] != int64(2000) || post.UpdatedAt != 2000 || post.CreatedAt != 5 {
+			t.Errorf("Expected updated 2000, got %v (model %d/%d)", q.Values, post.CreatedAt, post.UpdatedAt)
+		}
+
+		// The clock also drives soft deletes
+		note := &MockNote{ID: 1}
+		db.Delete(note, orm.Eq("id", 1))
+		if note.DeletedAt != 2000 {
+			t.Errorf("Expected deleted_at 2000, got %d", note.DeletedAt)
+		}
+	})
+
+	// Test tenant scoping and custom scopes
+	t.Run("Scopes", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockTxExecutor{}
+		db := orm.New(mockExec, mockCompiler).WithTenant("tenant_id", "t1")
+		model := &MockModel{Table: "item", Sch: []fmt.Field{{Name: "id"}, {Name: "tenant_id"}}, Vals: []any{1, "other"}}
+
+		hasTenantCond := func(q orm.Query) bool {
+			last := q.Conditions[len(q.Conditions)-1]
+			return last.Field() == "tenant_id" && last.Value() == "t1" && last.Logic() == "AND"
+		}
+
+		if err := db.Create(model); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if q := mockCompiler.LastQuery; q.Values[1] != "t1" {
+			t.Errorf("Expected tenant value on create, got %v", q.Values)
+		}
+
+		// The tenant is set on the model before validation
+		post := &MockTenantPost{ID: "p1"}
+		if err := db.Create(post); err != nil || post.TenantID != "t1" {
+			t.Errorf("Expected validated create with tenant t1, got %q, %v", post.TenantID, err)
+		}
+		post = &MockTenantPost{ID: "p1", TenantID: "other"}
+		if err := db.Update(post, orm.Eq("id", "p1")); err != nil || post.TenantID != "t1" {
+			t.Errorf("Expected update to overwrite the tenant, got %q, %v", post.TenantID, err)
+		}
+		if err := db.Unscoped().Create(&MockTenantPost{ID: "p2"}); err == nil {
+			t.Error("Expected validation error without tenant when Unscoped")
+		}
+
+		db.Update(model, orm.Eq("id", 1), orm.Or(orm.Eq("id", 2)))
+		q := mockCompiler.LastQuery
+		if q.Values[1] != "t1" || !hasTenantCond(q) || q.Conditions[0].Operator() != "GROUP" {
+			t.Errorf("Expected tenant value and grouped tenant condition on update, got %+v", q)
+		}
+
+		db.Delete(model, orm.Eq("id", 1))
+		if !hasTenantCond(mockCompiler.LastQuery) {
+			t.Errorf("Expected tenant condition on delete, got %+v", mockCompiler.LastQuery.Conditions)
+		}
+
+		db.Query(model).ReadOne()
+		if q := mockCompiler.LastQuery; len(q.Conditions) != 1 || !hasTenantCond(q) {
+			t.Errorf("Expected tenant condition on read, got %+v", q.Conditions)
+		}
+
+		// Transactions keep the scope
+		db.Tx(func(tx *orm.DB) error {
+			return tx.Query(model).ReadAll(func() fmt.Model { return &MockModel{} }, func(fmt.Model) {})
+		})
+		if !hasTenantCond(mockCompiler.LastQuery) {
+			t.Errorf("Expected tenant condition inside Tx, got %+v", mockCompiler.LastQuery.Conditions)
+		}
+
+		// Models without the tenant column are untouched
+		db.Query(&MockModel{Table: "country"}).ReadOne()
+		if len(mockCompiler.LastQuery.Conditions) != 0 {
+			t.Errorf("Expected no conditions for unscoped table, got %+v", mockCompiler.LastQuery.Conditions)
+		}
+
+		// Raw access is refused unless Unscoped
+		if err := db.RawExecutor().Exec("DELETE FROM item"); !errors.Is(err, orm.ErrScoped) {
+			t.Errorf("Expected ErrScoped from raw Exec, got %v", err)
+		}
+		if err := db.RawExecutor().QueryRow("SELECT 1").Scan(); !errors.Is(err, orm.ErrScoped) {
+			t.Errorf("Expected ErrScoped from raw QueryRow, got %v", err)
+		}
+		if db.Unscoped().RawExecutor() != mockExec {
+			t.Error("Expected the real executor from Unscoped()")
+		}
+
+		db.Unscoped().Query(model).ReadOne()
+		if len(mockCompiler.LastQuery.Conditions) != 0 {
+			t.Errorf("Expected no conditions when Unscoped, got %+v", mockCompiler.LastQuery.Conditions)
+		}
+
+		// Custom scopes run in order on every query
+		var seen []orm.Action
+		scoped := orm.New(&MockExecutor{}, mockCompiler).WithScope(func(q *orm.Query, m fmt.Model) {
+			seen = append(seen, q.Action)
+			q.Limit = 5
+		})
+		scoped.Query(model).ReadAll(func() fmt.Model { return &MockModel{} }, func(fmt.Model) {})
+		scoped.CreateTable(model)
+		if !reflect.DeepEqual(seen, []orm.Action{orm.ActionReadAll, orm.ActionCreateTable}) || mockCompiler.LastQuery.Limit != 5 {
+			t.Errorf("Expected scope on every query, got %v", seen)
+		}
+	})
+
+	// Test reusable QB scopes
+	t.Run("QB Scope", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		db := orm.New(&MockExecutor{}, mockCompiler)
+		active := func(qb *orm.QB) *orm.QB { return qb.Where("active").Eq(true) }
+		newest := func(qb *orm.QB) *orm.QB { return qb.OrderBy("id").Desc() }
+
+		err := db.Query(&MockModel{Table: "user"}).Scope(active, newest).Where("age").Gt(18).ReadOne()
+		if err != nil {
+			t.Fatalf("ReadOne failed: %v", err)
+		}
+		q := mockCompiler.LastQuery
+		if len(q.Conditions) != 2 || q.Conditions[0].Field() != "active" || q.Conditions[1].Field() != "age" {
+			t.Errorf("Expected scope conditions first, got %+v", q.Conditions)
+		}
+		if len(q.OrderBy) != 1 || q.OrderBy[0].Dir() != "DESC" {
+			t.Errorf("Expected scope ordering, got %+v", q.OrderBy)
+		}
+	})
+
+	// Test keyset pagination cursors
+	t.Run("Keyset Pagination", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		db := orm.New(&MockExecutor{}, mockCompiler)
+
+		// Cursor values round-trip with their types
+		last := &MockTask{ID: 7, Title: "b", Priority: 2}
+		c := db.Query(&MockTask{}).OrderBy("priority").Desc().OrderBy("title").Asc().Cursor(last)
+		parsed, err := orm.ParseCursor(c.String())
+		if err != nil {
+			t.Fatalf("ParseCursor failed: %v", err)
+		}
+		if parsed.String() != c.String() {
+			t.Errorf("Expected stable encoding, got %s vs %s", parsed, c)
+		}
+
+		// First page: zero cursor adds the PK tiebreaker without conditions
+		zero, err := orm.ParseCursor("")
+		if err != nil || !zero.IsZero() {
+			t.Fatalf("Expected zero cursor, got %v, %v", zero, err)
+		}
+		db.Query(&MockTask{}).OrderBy("priority").Desc().After(zero).Limit(10).ReadAll(func() fmt.Model { return &MockTask{} }, func(fmt.Model) {})
+		q := mockCompiler.LastQuery
+		if len(q.Conditions) != 0 || len(q.OrderBy) != 2 || q.OrderBy[1].Column() != "id" || q.OrderBy[1].Dir() != "DESC" {
+			t.Errorf("Expected priority DESC, id DESC without conditions, got %+v / %+v", q.OrderBy, q.Conditions)
+		}
+
+		// Next page: (priority < 2) OR (priority = 2 AND title > 'b') OR (priority = 2 AND title = 'b' AND id > 7)
+		db.Query(&MockTask{}).Where("status").Eq("open").OrderBy("priority").Desc().OrderBy("title").Asc().After(parsed).ReadOne()
+		q = mockCompiler.LastQuery
+		if len(q.Conditions) != 2 || q.Conditions[1].Operator() != "GROUP" {
+			t.Fatalf("Expected user condition plus keyset group, got %+v", q.Conditions)
+		}
+		branches := q.Conditions[1].Group()
+		if len(branches) != 3 || branches[1].Logic() != "OR" || branches[2].Logic() != "OR" {
+			t.Fatalf("Expected 3 OR branches, got %+v", branches)
+		}
+		first := branches[0].Group()
+		if len(first) != 1 || first[0].Operator() != "<" || first[0].Value() != int64(2) {
+			t.Errorf("Expected priority < 2, got %+v", first)
+		}
+		third := branches[2].Group()
+		if len(third) != 3 || third[1].Value() != "b" || third[2].Field() != "id" || third[2].Operator() != ">" || third[2].Value() != int64(7) {
+			t.Errorf("Expected priority = 2 AND title = 'b' AND id > 7, got %+v", third)
+		}
+
+		// Before reverses the order and the comparisons
+		db.Query(&MockTask{}).OrderBy("priority").Desc().OrderBy("title").Asc().Before(parsed).ReadOne()
+		q = mockCompiler.LastQuery
+		if q.OrderBy[0].Dir() != "ASC" || q.OrderBy[1].Dir() != "DESC" || q.OrderBy[2].Dir() != "DESC" {
+			t.Errorf("Expected reversed order, got %+v", q.OrderBy)
+		}
+		if op := q.Conditions[0].Group()[0].Group()[0].Operator(); op != ">" {
+			t.Errorf("Expected priority > 2 before cursor, got %s", op)
+		}
+
+		// Cursor not matching the sort columns
+		err = db.Query(&MockTask{}).OrderBy("title").Asc().After(parsed).ReadOne()
+		if !errors.Is(err, orm.ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+		for _, qb := range []*orm.QB{
+			db.Query(&MockTask{}).OrderBy("status").Desc().OrderBy("title").Asc(),
+			db.Query(&MockTask{}).OrderBy("priority").Asc().OrderBy("title").Asc(),
+		} {
+			if err := qb.After(parsed).ReadOne(); !errors.Is(err, orm.ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor for other sort columns, got %v", err)
+			}
+		}
+		for _, bad := range []string{"zz", "abc", "69", "7300000010"} {
+			if _, err := orm.ParseCursor(bad); !errors.Is(err, orm.ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor for %q, got %v", bad, err)
+			}
+		}
+	})
+
+	// Test Count and Paginate
+	t.Run("Count and Paginate", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockExecutor{ReturnQueryRow: &MockScanner{Vals: []any{int64(25)}}}
+		db := orm.New(mockExec, mockCompiler)
+
+		n, err := db.Query(&MockNote{}).Where("text").Eq("a").OrderBy("id").Asc().Limit(5).Count()
+		if err != nil || n != 25 {
+			t.Fatalf("Expected count 25, got %d, %v", n, err)
+		}
+		q := mockCompiler.LastQuery
+		if q.Action != orm.ActionCount || len(q.OrderBy) != 0 || q.Limit != 0 || len(q.Conditions) != 2 {
+			t.Errorf("Expected bare count query with soft-delete filter, got %+v", q)
+		}
+		if _, err := db.Query(&MockModel{}).Count(); !errors.Is(err, orm.ErrEmptyTable) {
+			t.Errorf("Expected ErrEmptyTable, got %v", err)
+		}
+
+		// Paginate runs count and read in one transaction
+		bound := &MockTxBoundExecutor{MockExecutor: MockExecutor{
+			ReturnQueryRow:  &MockScanner{Vals: []any{int64(25)}},
+			ReturnQueryRows: &MockRows{Count: 10},
+		}}
+		db = orm.New(&MockTxExecutor{Bound: bound}, mockCompiler)
+		qb := db.Query(&MockTask{}).Where("status").Eq("open")
+		page, err := orm.Paginate[MockTask](qb, 2, 10)
+		if err != nil {
+			t.Fatalf("Paginate failed: %v", err)
+		}
+		if len(page.Items) != 10 || page.Total != 25 || page.Page != 2 || page.PerPage != 10 || !page.HasNext {
+			t.Errorf("Unexpected page %+v", page)
+		}
+		if !bound.CommitCalled || len(bound.ExecutedQueries) != 2 {
+			t.Errorf("Expected count and read in a committed Tx, got %d queries", len(bound.ExecutedQueries))
+		}
+		q = mockCompiler.LastQuery
+		if q.Limit != 10 || q.Offset != 10 || len(q.Conditions) != 1 {
+			t.Errorf("Expected limit 10 offset 10 with the QB conditions, got %+v", q)
+		}
+
+		// Last page, without transaction support
+		mockExec = &MockExecutor{ReturnQueryRow: &MockScanner{Vals: []any{int64(25)}}, ReturnQueryRows: &MockRows{Count: 5}}
+		db = orm.New(mockExec, mockCompiler)
+		page, err = orm.Paginate[MockTask](db.Query(&MockTask{}), 3, 10)
+		if err != nil || page.HasNext || len(page.Items) != 5 {
+			t.Errorf("Expected last page of 5 items, got %+v, %v", page, err)
+		}
+		if _, err := orm.Paginate[MockTask](db.Query(&MockTask{}), 1, 0); err == nil {
+			t.Error("Expected error for perPage 0")
+		}
+
+		// A grouped count counts the groups
+		mockExec.ReturnQueryRows = &MockRows{Count: 4}
+		n, err = db.Query(&MockTask{}).GroupBy("status").Count()
+		if err != nil || n != 4 {
+			t.Errorf("Expected 4 groups, got %d, %v", n, err)
+		}
+		if len(mockCompiler.LastQuery.GroupBy) != 1 {
+			t.Errorf("Expected grouped count query, got %+v", mockCompiler.LastQuery)
+		}
+
+		// Inside a transaction Paginate runs on it, without nesting
+		reentrant := &MockReentrantTxExecutor{MockTxBoundExecutor: MockTxBoundExecutor{MockExecutor: MockExecutor{
+			ReturnQueryRow:  &MockScanner{Vals: []any{int64(3)}},
+			ReturnQueryRows: &MockRows{Count: 3},
+		}}}
+		err = orm.New(reentrant, mockCompiler).Tx(func(tx *orm.DB) error {
+			page, err := orm.Paginate[MockTask](tx.Query(&MockTask{}), 1, 10)
+			if err == nil && page.Total != 3 {
+				t.Errorf("Expected total 3, got %+v", page)
+			}
+			return err
+		})
+		if err != nil || reentrant.BeginCalls != 1 || !reentrant.CommitCalled {
+			t.Errorf("Expected Paginate in the outer Tx only, got %v (%d begins)", err, reentrant.BeginCalls)
+		}
+	})
+
+	// Test generic Collect, First and Each
+	t.Run("Collect First Each", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockExecutor{ReturnQueryRows: &MockRows{Count: 3}}
+		db := orm.New(mockExec, mockCompiler)
+
+		tasks, err := orm.Collect[MockTask](db.Query(&MockTask{}))
+		if err != nil || len(tasks) != 3 {
+			t.Fatalf("Expected 3 tasks, got %d, %v", len(tasks), err)
+		}
+
+		rows := &MockRows{Count: 3}
+		mockExec.ReturnQueryRows = rows
+		qb := db.Query(&MockTask{}).Limit(50)
+		first, err := orm.First[MockTask](qb)
+		if err != nil || first == nil {
+			t.Fatalf("Expected first task, got %v, %v", first, err)
+		}
+		if mockCompiler.LastQuery.Limit != 1 || rows.Current != 1 {
+			t.Errorf("Expected limit 1 and one row read, got limit %d, %d rows", mockCompiler.LastQuery.Limit, rows.Current)
+		}
+		mockExec.ReturnQueryRows = &MockRows{}
+		if _, err := orm.First[MockTask](qb); !errors.Is(err, orm.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if _, err := orm.Collect[MockTask](qb); err != nil || mockCompiler.LastQuery.Limit != 50 {
+			t.Errorf("Expected qb limit untouched by First, got %d, %v", mockCompiler.LastQuery.Limit, err)
+		}
+
+		// Each stops early when fn returns false
+		rows = &MockRows{Count: 5}
+		mockExec.ReturnQueryRows = rows
+		seen := 0
+		err = orm.Each[MockTask](db.Query(&MockTask{}), func(*MockTask) bool {
+			seen++
+			return seen < 2
+		})
+		if err != nil || seen != 2 || rows.Current != 2 {
+			t.Errorf("Expected stop after 2 rows, got %d seen, %d read, %v", seen, rows.Current, err)
+		}
+
+		mockExec.ReturnQueryRows = &MockRows{Count: 1, ScanErr: errors.New("scan err")}
+		if err := orm.Each[MockTask](db.Query(&MockTask{}), func(*MockTask) bool { return true }); err == nil {
+			t.Error("Expected scan error from Each")
+		}
+	})
+
+	// Test ReadAllE early termination
+	t.Run("ReadAllE", func(t *testing.T) {
+		rows := &MockRows{Count: 5}
+		db := orm.New(&MockExecutor{ReturnQueryRows: rows}, &MockCompiler{})
+		newTask := func() fmt.Model { return &MockTask{} }
+
+		seen := 0
+		err := db.Query(&MockTask{}).ReadAllE(newTask, func(fmt.Model) error {
+			seen++
+			if seen == 2 {
+				return orm.ErrStop
+			}
+			return nil
+		})
+		if err != nil || seen != 2 || rows.Current != 2 || !rows.Closed {
+			t.Errorf("Expected ErrStop to end after 2 rows, got %d seen, %d read, closed=%v, %v", seen, rows.Current, rows.Closed, err)
+		}
+
+		// A wrapped ErrStop ends the read too
+		rows = &MockRows{Count: 5}
+		db = orm.New(&MockExecutor{ReturnQueryRows: rows}, &MockCompiler{})
+		err = db.Query(&MockTask{}).ReadAllE(newTask, func(fmt.Model) error {
+			return errors.Join(errors.New("page full"), orm.ErrStop)
+		})
+		if err != nil || rows.Current != 1 || !rows.Closed {
+			t.Errorf("Expected wrapped ErrStop to end after 1 row, got %d read, closed=%v, %v", rows.Current, rows.Closed, err)
+		}
+
+		rows = &MockRows{Count: 5}
+		db = orm.New(&MockExecutor{ReturnQueryRows: rows}, &MockCompiler{})
+		badRow := errors.New("bad row")
+		seen = 0
+		err = db.Query(&MockTask{}).ReadAllE(newTask, func(fmt.Model) error {
+			seen++
+			return badRow
+		})
+		if err != badRow || seen != 1 || rows.Current != 1 || !rows.Closed {
+			t.Errorf("Expected callback error after 1 row, got %d seen, %d read, closed=%v, %v", seen, rows.Current, rows.Closed, err)
+		}
+	})
+
+	// Test streaming with QB.Iter
+	t.Run("Iter", func(t *testing.T) {
+		rows := &MockRows{Count: 5}
+		db := orm.New(&MockExecutor{ReturnQueryRows: rows}, &MockCompiler{})
+		model := &MockTask{}
+
+		n := 0
+		for m, err := range db.Query(model).Iter() {
+			if err != nil {
+				t.Fatalf("Iter error: %v", err)
+			}
+			if m != model {
+				t.Error("Expected rows scanned into the query model")
+			}
+			n++
+			if n == 2 {
+				break
+			}
+		}
+		if n != 2 || rows.Current != 2 {
+			t.Errorf("Expected break after 2 rows, got %d yielded, %d read", n, rows.Current)
+		}
+
+		// Errors surface once, with a nil model
+		db = orm.New(&MockExecutor{ReturnQueryRows: &MockRows{Count: 3, ScanErr: errors.New("scan err")}}, &MockCompiler{})
+		var errs []error
+		for m, err := range db.Query(model).Iter() {
+			if m != nil {
+				t.Error("Expected nil model with error")
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) != 1 || errs[0].Error() != "scan err" {
+			t.Errorf("Expected one scan error, got %v", errs)
+		}
+	})
+
+	// Test raw SQL reads and writes
+	t.Run("Raw", func(t *testing.T) {
+		const cte = "WITH recent AS (SELECT * FROM task) SELECT * FROM recent WHERE id > ?"
+		rows := &MockRows{Count: 3}
+		mockExec := &MockResultExecutor{MockExecutor: MockExecutor{ReturnQueryRows: rows}, Result: orm.Result{RowsAffected: 4, LastInsertID: -1}}
+		db := orm.New(mockExec, &MockCompiler{})
+
+		var read []*MockHookTask
+		err := db.Raw(cte, 7).ReadAll(func() fmt.Model { return &MockHookTask{} }, func(m fmt.Model) {
+			read = append(read, m.(*MockHookTask))
+		})
+		if err != nil || len(read) != 3 || !rows.Closed {
+			t.Fatalf("Expected 3 rows read and closed, got %d, %v", len(read), err)
+		}
+		if len(read[0].Calls) != 1 || read[0].Calls[0] != "AfterRead" {
+			t.Errorf("Expected AfterRead hook per row, got %v", read[0].Calls)
+		}
+		if mockExec.ExecutedQueries[0] != cte || mockExec.ExecutedArgs[0][0] != 7 {
+			t.Errorf("Expected raw query passed as is, got %q %v", mockExec.ExecutedQueries[0], mockExec.ExecutedArgs[0])
+		}
+
+		one := &MockHookTask{}
+		if err := db.Raw("SELECT * FROM task LIMIT 1").ReadOne(one); err != nil || len(one.Calls) != 1 {
+			t.Errorf("Expected ReadOne with AfterRead hook, got %v, %v", one.Calls, err)
+		}
+
+		res, err := db.Raw("UPDATE task SET done = 1 WHERE due < ?", 100).Exec()
+		if err != nil || res.RowsAffected != 4 {
+			t.Errorf("Expected Exec result, got %+v, %v", res, err)
+		}
+
+		// A scoped DB refuses raw SQL unless unscoped
+		scoped := db.WithTenant("tenant_id", 1)
+		if _, err := scoped.Raw("DELETE FROM task").Exec(); err != orm.ErrScoped {
+			t.Errorf("Expected ErrScoped on scoped Exec, got %v", err)
+		}
+		if err := scoped.Raw("SELECT 1").ReadOne(&MockTask{}); err != orm.ErrScoped {
+			t.Errorf("Expected ErrScoped on scoped ReadOne, got %v", err)
+		}
+		if _, err := scoped.Unscoped().Raw("DELETE FROM task").Exec(); err != nil {
+			t.Errorf("Expected Unscoped Raw to run, got %v", err)
+		}
+	})
+
+	// Test reading result rows (DTOs) from QB and Raw
+	t.Run("Result Rows", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockExecutor{ReturnQueryRows: &MockRows{Count: 2}}
+		db := orm.New(mockExec, mockCompiler)
+
+		// QB selects the result columns, in order, from the builder table
+		rows, err := orm.CollectAs[MockRegionTotal](db.Query(&MockTask{}).Where("done").Eq(true))
+		if err != nil || len(rows) != 2 {
+			t.Fatalf("Expected 2 result rows, got %d, %v", len(rows), err)
+		}
+		q := mockCompiler.LastQuery
+		if q.Table != "task" || strings.Join(q.Columns, ",") != "region,total" || len(q.Conditions) != 1 {
+			t.Errorf("Expected result columns selected from task, got %+v", q)
+		}
+
+		// Raw scans by column name when Rows report their columns
+		mockExec.ReturnQueryRows = &MockColumnRows{
+			Cols: []string{"total", "extra", "region"},
+			Data: [][]any{{int64(30), "x", "north"}, {int64(12), "y", "south"}},
+		}
+		rows, err = orm.CollectAs[MockRegionTotal](db.Raw("SELECT SUM(total) AS total, 'x' AS extra, region FROM orders GROUP BY region"))
+		if err != nil || len(rows) != 2 {
+			t.Fatalf("Expected 2 raw result rows, got %d, %v", len(rows), err)
+		}
+		if *rows[0] != (MockRegionTotal{Region: "north", Total: 30}) || *rows[1] != (MockRegionTotal{Region: "south", Total: 12}) {
+			t.Errorf("Expected rows scanned by name, got %+v %+v", *rows[0], *rows[1])
+		}
+
+		if _, err := orm.CollectAs[MockRegionTotal](db.WithTenant("tenant_id", 1).Raw("SELECT 1")); err != orm.ErrScoped {
+			t.Errorf("Expected ErrScoped on scoped raw read, got %v", err)
+		}
+	})
+
+	// Test view models: DDL and read-only writes
+	t.Run("Views", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		mockExec := &MockExecutor{}
+		db := orm.New(mockExec, mockCompiler)
+		view := &MockOpenTask{}
+
+		if err := db.CreateView(view); err != nil {
+			t.Fatalf("CreateView failed: %v", err)
+		}
+		q := mockCompiler.LastQuery
+		if q.Action != orm.ActionCreateView || q.Table != "open_tasks" || q.ViewQuery != view.ViewQuery() {
+			t.Errorf("Unexpected CreateView query: %+v", q)
+		}
+		if err := db.DropView(view); err != nil || mockCompiler.LastQuery.Action != orm.ActionDropView {
+			t.Errorf("Expected ActionDropView, got %v, %v", mockCompiler.LastQuery.Action, err)
+		}
+
+		// Views read like tables
+		if err := db.Query(view).Where("priority").Gt(1).ReadOne(); err != nil || mockCompiler.LastQuery.Table != "open_tasks" {
+			t.Errorf("Expected read from the view, got %+v, %v", mockCompiler.LastQuery, err)
+		}
+
+		// Writes fail before any hook or query runs
+		mockExec.ExecutedQueries = nil
+		view.Calls = nil
+		cond := orm.Eq("id", 1)
+		writes := map[string]error{
+			"Create":      db.Create(view),
+			"Update":      db.Update(view, cond),
+			"Delete":      db.Delete(view, cond),
+			"Restore":     db.Restore(view, cond),
+			"ForceDelete": db.ForceDelete(view, cond),
+		}
+		for name, err := range writes {
+			if err != orm.ErrReadOnlyView {
+				t.Errorf("%s: expected ErrReadOnlyView, got %v", name, err)
+			}
+		}
+		if len(mockExec.ExecutedQueries) != 0 || len(view.Calls) != 0 {
+			t.Errorf("Expected no queries or hooks, got %v %v", mockExec.ExecutedQueries, view.Calls)
+		}
+	})
+
+	// Test read/write splitting across primary and replicas
+	t.Run("Replicas", func(t *testing.T) {
+		primary := &MockTxExecutor{Bound: &MockTxBoundExecutor{}}
+		r1, r2 := &MockExecutor{}, &MockExecutor{}
+		db := orm.New(primary, &MockCompiler{}, orm.WithReplicas(
+			orm.Replica{Exec: r1},
+			orm.Replica{Exec: r2, Weight: 2},
+		))
+		newTask := func() fmt.Model { return &MockTask{} }
+
+		for i := 0; i < 5; i++ {
+			db.Query(&MockTask{}).ReadAll(newTask, func(fmt.Model) {})
+		}
+		db.Query(&MockTask{}).Count()
+		if len(r1.ExecutedQueries) != 2 || len(r2.ExecutedQueries) != 4 {
+			t.Errorf("Expected weighted 2/4 split, got %d/%d", len(r1.ExecutedQueries), len(r2.ExecutedQueries))
+		}
+
+		// Writes, Primary() reads and transactions use the primary
+		db.Create(&MockTask{Title: "x"})
+		db.Query(&MockTask{}).Primary().ReadOne()
+		if len(primary.ExecutedQueries) != 2 {
+			t.Errorf("Expected write and Primary() read on primary, got %v", primary.ExecutedQueries)
+		}
+		db.Tx(func(tx *orm.DB) error {
+			return tx.Query(&MockTask{}).ReadAll(newTask, func(fmt.Model) {})
+		})
+		if len(primary.Bound.ExecutedQueries) != 1 || len(r1.ExecutedQueries)+len(r2.ExecutedQueries) != 6 {
+			t.Errorf("Expected the Tx read on the primary transaction, got %v", primary.Bound.ExecutedQueries)
+		}
+
+		// Scoped copies keep routing reads to the replicas
+		db.WithTenant("tenant_id", 1).Query(&MockTask{}).ReadOne()
+		if len(r1.ExecutedQueries)+len(r2.ExecutedQueries) != 7 {
+			t.Error("Expected scoped reads on a replica")
+		}
+
+		// Close closes the replicas too
+		r2.ReturnCloseErr = errors.New("replica close err")
+		if err := db.Close(); err == nil || err.Error() != "replica close err" {
+			t.Errorf("Expected replica close error, got %v", err)
+		}
+	})
+
+	t.Run("Sharding", func(t *testing.T) {
+		s0, s1 := &MockExecutor{}, &MockExecutor{}
+		sharded := orm.NewShardedExecutor("priority", nil, s0, s1)
+		db := orm.New(sharded, &MockCompiler{})
+		newTask := func() fmt.Model { return &MockTask{} }
+		reset := func() { s0.ExecutedQueries, s1.ExecutedQueries = nil, nil }
+
+		// Eq on the shard key reads one shard
+		db.Query(&MockTask{}).Where("priority").Eq(3).ReadOne()
+		if len(s0.ExecutedQueries) != 0 || len(s1.ExecutedQueries) != 1 {
+			t.Errorf("Expected read on shard 1, got %d/%d", len(s0.ExecutedQueries), len(s1.ExecutedQueries))
+		}
+		reset()
+
+		// Create routes by the key column value
+		if err := db.Create(&MockTask{Title: "x", Priority: 4}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if len(s0.ExecutedQueries) != 1 || len(s1.ExecutedQueries) != 0 {
+			t.Errorf("Expected create on shard 0, got %d/%d", len(s0.ExecutedQueries), len(s1.ExecutedQueries))
+		}
+		reset()
+
+		// In over keys on both shards fans out; rows merge up to Limit
+		s0.ReturnQueryRows, s1.ReturnQueryRows = &MockRows{Count: 2}, &MockRows{Count: 2}
+		var n int
+		err := db.Query(&MockTask{}).Where("priority").In([]int{1, 2}).Limit(3).ReadAll(newTask, func(fmt.Model) { n++ })
+		if err != nil || n != 3 || len(s0.ExecutedQueries) != 1 || len(s1.ExecutedQueries) != 1 {
+			t.Errorf("Expected 3 merged rows from both shards, got %d (err %v)", n, err)
+		}
+		reset()
+
+		// Count without a key sums the shard counts
+		s0.ReturnQueryRow = &MockScanner{Vals: []any{int64(2)}}
+		s1.ReturnQueryRow = &MockScanner{Vals: []any{int64(5)}}
+		if count, err := db.Query(&MockTask{}).Count(); err != nil || count != 7 {
+			t.Errorf("Expected summed count 7, got %d (err %v)", count, err)
+		}
+		reset()
+
+		// Reads spanning shards cannot be ordered, grouped or offset
+		for name, qb := range map[string]*orm.QB{
+			"offset":   db.Query(&MockTask{}).Offset(1),
+			"order":    db.Query(&MockTask{}).OrderBy("title").Desc().Limit(10),
+			"keyset":   db.Query(&MockTask{}).Where("priority").In([]int{1, 2}).After(orm.Cursor{}),
+			"group by": db.Query(&MockTask{}).GroupBy("status"),
+		} {
+			if err := qb.ReadAll(newTask, func(fmt.Model) {}); !errors.Is(err, orm.ErrUnroutable) {
+				t.Errorf("Expected ErrUnroutable for fan-out %s, got %v", name, err)
+			}
+		}
+		if _, err := db.Query(&MockTask{}).GroupBy("status").Count(); !errors.Is(err, orm.ErrUnroutable) {
+			t.Errorf("Expected ErrUnroutable for fan-out grouped count, got %v", err)
+		}
+		if len(s0.ExecutedQueries)+len(s1.ExecutedQueries) != 0 {
+			t.Errorf("Expected no query for refused fan-out reads, got %d", len(s0.ExecutedQueries)+len(s1.ExecutedQueries))
+		}
+		// An ordered read on one shard is fine
+		if err := db.Query(&MockTask{}).Where("priority").Eq(2).OrderBy("title").Desc().ReadAll(newTask, func(fmt.Model) {}); err != nil {
+			t.Errorf("Expected single-shard ordered read, got %v", err)
+		}
+		reset()
+
+		// Writes need the key; OR conditions cannot be routed
+		if err := db.Update(&MockTask{Title: "y"}, orm.Eq("status", "open")); !errors.Is(err, orm.ErrUnroutable) {
+			t.Errorf("Expected ErrUnroutable for update without key, got %v", err)
+		}
+		if err := db.Delete(&MockTask{}, orm.Eq("priority", 1), orm.Or(orm.Eq("status", "done"))); !errors.Is(err, orm.ErrUnroutable) {
+			t.Errorf("Expected ErrUnroutable for OR delete, got %v", err)
+		}
+		if len(s0.ExecutedQueries)+len(s1.ExecutedQueries) != 0 {
+			t.Error("Expected no query for unroutable writes")
+		}
+
+		// The tenant scope carries the key
+		if err := db.WithTenant("priority", 7).Update(&MockTask{Title: "y"}, orm.Eq("status", "open")); err != nil {
+			t.Errorf("Expected tenant scoped update to route, got %v", err)
+		}
+		if len(s1.ExecutedQueries) != 1 {
+			t.Errorf("Expected update on shard 1, got %v", s1.ExecutedQueries)
+		}
+
+		// Raw SQL has no key
+		if _, err := db.Raw("DELETE FROM task").Exec(); !errors.Is(err, orm.ErrUnroutable) {
+			t.Errorf("Expected ErrUnroutable for raw SQL, got %v", err)
+		}
+		if sh, err := sharded.Shard(int64(9)); err != nil || sh != s1 {
+			t.Errorf("Expected Shard(9) to be shard 1, got %v", err)
+		}
+
+		// Shard misconfiguration is an error, not a panic
+		empty := orm.New(orm.NewShardedExecutor("priority", nil), &MockCompiler{})
+		if err := empty.Query(&MockTask{}).Where("priority").Eq(1).ReadOne(); !errors.Is(err, orm.ErrUnroutable) {
+			t.Errorf("Expected ErrUnroutable without shards, got %v", err)
+		}
+		bad := orm.New(orm.NewShardedExecutor("priority", func(any) int { return 2 }, s0, s1), &MockCompiler{})
+		if err := bad.Create(&MockTask{Priority: 1}); !errors.Is(err, orm.ErrUnroutable) {
+			t.Errorf("Expected ErrUnroutable for out of range shard, got %v", err)
+		}
+	})
+
+	t.Run("SchemaExt", func(t *testing.T) {
+		plain := &MockModel{Table: "t", Sch: []fmt.Field{{Name: "a"}, {Name: "b"}}}
+		ext := orm.SchemaExt(plain)
+		if len(ext) != 2 || ext[1].Name != "b" || ext[1].Default != "" {
+			t.Errorf("Expected wrapped plain schema, got %+v", ext)
+		}
+
+		tm := orm.TableModel("t", orm.FieldExt{Field: fmt.Field{Name: "a"}, Default: "0"})
+		if got := orm.SchemaExt(tm); len(got) != 1 || got[0].Default != "0" {
+			t.Errorf("Expected TableModel ext, got %+v", got)
+		}
+		if len(tm.Schema()) != 1 || tm.Schema()[0].Name != "a" {
+			t.Errorf("Expected TableModel schema [a], got %+v", tm.Schema())
+		}
+	})
+
+	// Test Index DDL Actions
+	t.Run("Index DDL", func(t *testing.T) {
+		mockCompiler := &MockCompiler{}
+		db := orm.New(&MockExecutor{}, mockCompiler)
+		model := &MockModel{Table: "user"}
+
+		idx := orm.Index{Name: "uq_user_email", Columns: []string{"tenant_id", "email"}, Unique: true}
+		if err := db.CreateIndex(model, idx); err != nil {
+			t.Fatalf("CreateIndex failed: %v", err)
+		}
+		if mockCompiler.LastQuery.Action != orm.ActionCreateIndex {
+			t.Errorf("Expected ActionCreateIndex, got %v", mockCompiler.LastQuery.Action)
+		}
+		if !reflect.DeepEqual(mockCompiler.LastQuery.Index, idx) {
+			t.Errorf("Expected index %+v, got %+v", idx, mockCompiler.LastQuery.Index)
+		}
+
+		if err := db.DropIndex(model, "uq_user_email"); err != nil {
+			t.Fatalf("DropIndex failed: %v", err)
+		}
+		if mockCompiler.LastQuery.Action != orm.ActionDropIndex {
+			t.Errorf("Expected ActionDropIndex, got %v", mockCompiler.LastQuery.Action)
+		}
+		if mockCompiler.LastQuery.Index.Name != "uq_user_email" {
+			t.Errorf("Expected index name 'uq_user_email', got '%s'", mockCompiler.LastQuery.Index.Name)
+		}
+
+		if err := db.DropIndex(&MockModel{}, "x"); !errors.Is(err, orm.ErrEmptyTable) {
+			t.Errorf("Expected ErrEmptyTable, got %v", err)
+		}
+	})
+
 	// 16. Errors coverage
 	t.Run("Errors", func(t *testing.T) {
 		model := &MockModel{Table: "t", Sch: []fmt.Field{{Name: "a"}}, Vals: []any{1}}
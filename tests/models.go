@@ -2,6 +2,8 @@ package tests
 
 import (
 	"time"
+
+	"github.com/tinywasm/orm"
 )
 
 //go:generate ormc
@@ -106,6 +108,14 @@ type LoginForm struct {
 	Password string `input:"password,required"`
 }
 
+// ormc:result
+type SalesReport struct {
+	ID     int64
+	Region string `db:"unique"`
+	Total  float64
+	Orders int64
+}
+
 type Address struct {
 	Street string
 	City   string
@@ -125,3 +135,56 @@ type WithPointers struct {
 	Count *int    // pointer to primitive -> should be skipped with warning
 	Addr  *Address // pointer to struct -> FieldStruct
 }
+
+// Indexed covers single, composite and composite-unique index tags.
+type Indexed struct {
+	ID       string `db:"pk"`
+	Email    string `db:"index"`
+	TenantID string `db:"index=idx_tenant_slug,unique=uq_tenant_code"`
+	Slug     string `db:"index=idx_tenant_slug"`
+	Code     string `db:"unique=uq_tenant_code"`
+}
+
+// Versioned covers db:"version" optimistic locking.
+type Versioned struct {
+	ID      string `db:"pk"`
+	Body    string
+	Version int64 `db:"version"`
+}
+
+// SoftDeleted covers db:"deleted_at" soft deletes.
+type SoftDeleted struct {
+	ID        string `db:"pk"`
+	DeletedAt int64  `db:"deleted_at"`
+}
+
+// Timestamped covers db:"created" and db:"updated".
+type Timestamped struct {
+	ID        string `db:"pk"`
+	CreatedAt int64  `db:"created"`
+	UpdatedAt int64  `db:"updated"`
+}
+
+// Scoped covers named query scopes declared as ScopeX methods.
+type Scoped struct {
+	ID     string `db:"pk"`
+	Active bool
+}
+
+func (Scoped) ScopeActive(qb *orm.QB) *orm.QB  { return qb.Where("active").Eq(true) }
+func (*Scoped) ScopeNewest(qb *orm.QB) *orm.QB { return qb.OrderBy("id").Desc() }
+
+// Not scopes: wrong signature, or bare "Scope".
+func (Scoped) ScopeCount(qb *orm.QB) int    { return 0 }
+func (Scoped) Scope(qb *orm.QB) *orm.QB     { return qb }
+func (Scoped) ScopeBy(a, b *orm.QB) *orm.QB { return a }
+
+// WithDefaults covers db:"default=..." on every supported type.
+type WithDefaults struct {
+	ID       string  `db:"pk"`
+	Status   string  `db:"default='pending'"`
+	Attempts int64   `db:"default=0"`
+	Ratio    float64 `db:"default=1.5"`
+	Enabled  bool    `db:"default=true"`
+	ParentID string  `db:"ref=with_defaults"`
+}
@@ -0,0 +1,203 @@
+//go:build !wasm
+
+package tests
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tinywasm/orm"
+)
+
+func TestOrmc_MigrateNew(t *testing.T) {
+	tmp := t.TempDir()
+	modelFile := filepath.Join(tmp, "model.go")
+	migrations := filepath.Join(tmp, "migrations")
+
+	v1 := `package app
+
+type Account struct {
+	ID   int64 ` + "`" + `db:"pk"` + "`" + `
+	Name string
+	Nick string
+}
+`
+	if err := os.WriteFile(modelFile, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := orm.NewOrmc()
+	o.SetRootDir(tmp)
+
+	t.Run("initial migration creates tables and snapshot", func(t *testing.T) {
+		path, err := o.MigrateNew("init")
+		if err != nil {
+			t.Fatalf("MigrateNew failed: %v", err)
+		}
+		if filepath.Dir(path) != migrations || !strings.HasSuffix(path, "_init.go") {
+			t.Errorf("unexpected migration path: %s", path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(content)
+		for _, expected := range []string{
+			"package migrations",
+			"func Migrate",
+			`db.CreateTable(orm.TableModel("account",`,
+			`orm.FieldExt{Field: fmt.Field{Name: "id", Type: fmt.FieldInt, DB: &fmt.FieldDB{PK: true}}},`,
+			`orm.FieldExt{Field: fmt.Field{Name: "nick", Type: fmt.FieldText}},`,
+		} {
+			if !strings.Contains(s, expected) {
+				t.Errorf("migration missing %q:\n%s", expected, s)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(migrations, "schema.json")); err != nil {
+			t.Errorf("expected schema snapshot: %v", err)
+		}
+	})
+
+	t.Run("no changes is an error", func(t *testing.T) {
+		if _, err := o.MigrateNew("noop"); err == nil {
+			t.Error("expected error when schema is unchanged")
+		}
+	})
+
+	t.Run("column changes produce add/alter/drop steps", func(t *testing.T) {
+		v2 := `package app
+
+type Account struct {
+	ID    int64  ` + "`" + `db:"pk"` + "`" + `
+	Name  string ` + "`" + `db:"not_null"` + "`" + `
+	Email string ` + "`" + `db:"unique,default=''"` + "`" + `
+}
+`
+		if err := os.WriteFile(modelFile, []byte(v2), 0644); err != nil {
+			t.Fatal(err)
+		}
+		path, err := o.MigrateNew("add email")
+		if err != nil {
+			t.Fatalf("MigrateNew failed: %v", err)
+		}
+		if !strings.HasSuffix(path, "_add_email.go") {
+			t.Errorf("unexpected migration path: %s", path)
+		}
+		content, _ := os.ReadFile(path)
+		s := string(content)
+		for _, expected := range []string{
+			"AddEmail(db *orm.DB) error {",
+			`db.AddColumn(orm.TableModel("account",`,
+			`orm.FieldExt{Field: fmt.Field{Name: "email", Type: fmt.FieldText, DB: &fmt.FieldDB{Unique: true}}, Default: "''"},`,
+			`db.AlterColumn(orm.TableModel("account",`,
+			`orm.FieldExt{Field: fmt.Field{Name: "name", Type: fmt.FieldText, NotNull: true}},`,
+			`db.DropColumn(orm.TableModel("account"), "nick")`,
+		} {
+			if !strings.Contains(s, expected) {
+				t.Errorf("migration missing %q:\n%s", expected, s)
+			}
+		}
+	})
+
+	t.Run("drop-only migration imports only what it uses", func(t *testing.T) {
+		v3 := `package app
+
+type Account struct {
+	ID   int64  ` + "`" + `db:"pk"` + "`" + `
+	Name string ` + "`" + `db:"not_null"` + "`" + `
+}
+`
+		if err := os.WriteFile(modelFile, []byte(v3), 0644); err != nil {
+			t.Fatal(err)
+		}
+		path, err := o.MigrateNew("drop email")
+		if err != nil {
+			t.Fatalf("MigrateNew failed: %v", err)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			t.Fatalf("migration does not parse: %v", err)
+		}
+		used := map[string]bool{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok {
+					used[id.Name] = true
+				}
+			}
+			return true
+		})
+		var imports []string
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			imports = append(imports, p)
+			if !used[filepath.Base(p)] {
+				t.Errorf("migration imports %s without using it", p)
+			}
+		}
+		if len(imports) != 1 || imports[0] != "github.com/tinywasm/orm" {
+			t.Errorf("expected only the orm import, got %v", imports)
+		}
+	})
+}
+
+func TestDiffSchemas_Indexes(t *testing.T) {
+	old := orm.SchemaSnapshot{Tables: []orm.TableSnapshot{{
+		Name:    "item",
+		Indexes: []orm.Index{{Name: "idx_a", Columns: []string{"a"}}, {Name: "idx_gone", Columns: []string{"b"}}},
+	}}}
+	new := orm.SchemaSnapshot{Tables: []orm.TableSnapshot{{
+		Name:    "item",
+		Indexes: []orm.Index{{Name: "idx_a", Columns: []string{"a", "b"}}, {Name: "idx_new", Columns: []string{"c"}, Unique: true}},
+	}}}
+
+	var kinds []string
+	for _, s := range orm.DiffSchemas(old, new) {
+		kinds = append(kinds, s.Kind+":"+s.Index.Name)
+	}
+	expected := []string{"drop_index:idx_a", "drop_index:idx_gone", "create_index:idx_a", "create_index:idx_new"}
+	if strings.Join(kinds, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected steps %v, got %v", expected, kinds)
+	}
+}
+
+func TestDiffSchemas_DropTable(t *testing.T) {
+	old := orm.SchemaSnapshot{Tables: []orm.TableSnapshot{{Name: "legacy"}}}
+	steps := orm.DiffSchemas(old, orm.SchemaSnapshot{})
+	if len(steps) != 1 || steps[0].Kind != "drop_table" || steps[0].Table.Name != "legacy" {
+		t.Errorf("expected single drop_table step, got %+v", steps)
+	}
+}
+
+func TestDiffSchemas_RefOrder(t *testing.T) {
+	post := orm.TableSnapshot{Name: "post", Columns: []orm.ColumnSnapshot{{Name: "id", Type: "int", PK: true}}}
+	comment := orm.TableSnapshot{Name: "comment", Columns: []orm.ColumnSnapshot{
+		{Name: "id", Type: "int", PK: true},
+		{Name: "post_id", Type: "int", Ref: "post"},
+	}}
+	schema := orm.SchemaSnapshot{Tables: []orm.TableSnapshot{comment, post}} // sorted by name, as Snapshot does
+
+	var kinds []string
+	for _, s := range orm.DiffSchemas(orm.SchemaSnapshot{}, schema) {
+		kinds = append(kinds, s.Kind+":"+s.Table.Name)
+	}
+	expected := []string{"create_table:post", "create_table:comment"}
+	if strings.Join(kinds, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected parent created first %v, got %v", expected, kinds)
+	}
+
+	kinds = nil
+	for _, s := range orm.DiffSchemas(schema, orm.SchemaSnapshot{}) {
+		kinds = append(kinds, s.Kind+":"+s.Table.Name)
+	}
+	expected = []string{"drop_table:comment", "drop_table:post"}
+	if strings.Join(kinds, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected child dropped first %v, got %v", expected, kinds)
+	}
+}
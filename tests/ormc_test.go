@@ -4,6 +4,7 @@ package tests
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -56,6 +57,51 @@ func TestOrmc(t *testing.T) {
 		}
 	})
 
+	t.Run("result directive", func(t *testing.T) {
+		info, err := orm.NewOrmc().ParseStruct("SalesReport", "models.go")
+		if err != nil {
+			t.Fatalf("ParseStruct failed: %v", err)
+		}
+		if !info.Result || info.IsForm {
+			t.Errorf("Expected a result struct, got %+v", info)
+		}
+
+		if err := orm.NewOrmc().GenerateForStruct("SalesReport", "models.go"); err != nil {
+			t.Fatalf("Failed to generate code for SalesReport: %v", err)
+		}
+		outFile := "models_orm.go"
+		contentBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		defer os.Remove(outFile)
+		content := string(contentBytes)
+
+		for _, expected := range []string{
+			"func (m *SalesReport) Schema() []fmt.Field {",
+			"func (m *SalesReport) Pointers() []any {",
+			"{Name: \"region\", Type: fmt.FieldText},",
+			"func ReadAllSalesReport(r orm.ResultReader) ([]*SalesReport, error) {",
+			"\treturn orm.CollectAs[SalesReport](r)",
+		} {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Generated file missing %q:\n%s", expected, content)
+			}
+		}
+		for _, forbidden := range []string{
+			"func (m *SalesReport) ModelName() string",
+			"func ReadOneSalesReport",
+			"func IterSalesReport",
+			"var SalesReport_ =",
+			"DB: &fmt.FieldDB",
+			"\"iter\"",
+		} {
+			if strings.Contains(content, forbidden) {
+				t.Errorf("Generated file contains forbidden %q", forbidden)
+			}
+		}
+	})
+
 	t.Run("Validate tags and Permitted", func(t *testing.T) {
 		err := orm.NewOrmc().GenerateForStruct("UserForm", "models.go")
 		if err != nil {
@@ -132,6 +178,9 @@ func TestOrmc(t *testing.T) {
 			"ID: \"id\"",
 			"func ReadOneUser(qb *orm.QB, model *User) (*User, error) {",
 			"func ReadAllUser(qb *orm.QB) ([]*User, error) {",
+			"\treturn orm.Collect[User](qb)",
+			"\t\"iter\"\n",
+			"func IterUser(qb *orm.QB) iter.Seq2[*User, error] {",
 		}
 
 		for _, expected := range expectedStrings {
@@ -200,6 +249,287 @@ func TestOrmc(t *testing.T) {
 		}
 	})
 
+	t.Run("Index Tags", func(t *testing.T) {
+		o := orm.NewOrmc()
+		info, err := o.ParseStruct("Indexed", "models.go")
+		if err != nil {
+			t.Fatalf("Failed to parse Indexed: %v", err)
+		}
+		if len(info.Indexes) != 3 {
+			t.Fatalf("Expected 3 indexes, got %+v", info.Indexes)
+		}
+
+		if err := o.GenerateForFile([]orm.StructInfo{info}, "models.go"); err != nil {
+			t.Fatalf("Failed to generate code for Indexed: %v", err)
+		}
+		outFile := "models_orm.go"
+		contentBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		defer os.Remove(outFile)
+
+		content := string(contentBytes)
+		expectedStrings := []string{
+			"var _indexesIndexed = []orm.Index{",
+			`{Name: "idx_indexed_email", Columns: []string{"email"}},`,
+			`{Name: "idx_tenant_slug", Columns: []string{"tenant_id", "slug"}},`,
+			`{Name: "uq_tenant_code", Columns: []string{"tenant_id", "code"}, Unique: true},`,
+			"func (m *Indexed) Indexes() []orm.Index { return _indexesIndexed }",
+		}
+		for _, expected := range expectedStrings {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Generated file missing expected string: %s\nContent:\n%s", expected, content)
+			}
+		}
+	})
+
+	t.Run("Index name used as both index and unique", func(t *testing.T) {
+		tmp := t.TempDir()
+		path := tmp + "/model.go"
+		src := "package app\n\ntype Bad struct {\n\tID string\n\tA string `db:\"index=x\"`\n\tB string `db:\"unique=x\"`\n}\n"
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := orm.NewOrmc().ParseStruct("Bad", path); err == nil {
+			t.Error("Expected error for conflicting index name")
+		}
+	})
+
+	t.Run("Default Tags", func(t *testing.T) {
+		err := orm.NewOrmc().GenerateForStruct("WithDefaults", "models.go")
+		if err != nil {
+			t.Fatalf("Failed to generate code for WithDefaults: %v", err)
+		}
+		outFile := "models_orm.go"
+		contentBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		defer os.Remove(outFile)
+
+		content := string(contentBytes)
+		expectedStrings := []string{
+			"var _schemaExtWithDefaults = []orm.FieldExt{",
+			"{Field: _schemaWithDefaults[0]},",
+			`{Field: _schemaWithDefaults[1], Default: "'pending'"},`,
+			`{Field: _schemaWithDefaults[2], Default: "0"},`,
+			`{Field: _schemaWithDefaults[3], Default: "1.5"},`,
+			`{Field: _schemaWithDefaults[4], Default: "true"},`,
+			`{Field: _schemaWithDefaults[5], Ref: "with_defaults"},`,
+			"func (m *WithDefaults) SchemaExt() []orm.FieldExt { return _schemaExtWithDefaults }",
+		}
+		for _, expected := range expectedStrings {
+			if !strings.Contains(content, expected) {
+				t.Errorf("Generated file missing expected string: %s\nContent:\n%s", expected, content)
+			}
+		}
+	})
+
+	t.Run("Invalid Default Tags", func(t *testing.T) {
+		cases := map[string]string{
+			"int from text":   "N int64 `db:\"default=abc\"`",
+			"unquoted string": "N string `db:\"default=pending\"`",
+			"bool":            "N bool `db:\"default=yes\"`",
+			"negative uint":   "N uint32 `db:\"default=-1\"`",
+			"float from text": "N float64 `db:\"default=x\"`",
+			"blob":            "N []byte `db:\"default='a'\"`",
+		}
+		tmp := t.TempDir()
+		for name, field := range cases {
+			t.Run(name, func(t *testing.T) {
+				path := tmp + "/model.go"
+				src := "package app\n\ntype Bad struct {\n\tID string\n\t" + field + "\n}\n"
+				if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := orm.NewOrmc().ParseStruct("Bad", path); err == nil {
+					t.Errorf("Expected error for %s", field)
+				}
+			})
+		}
+	})
+
+	t.Run("Version Tag", func(t *testing.T) {
+		info, err := orm.NewOrmc().ParseStruct("Versioned", "models.go")
+		if err != nil {
+			t.Fatalf("ParseStruct failed: %v", err)
+		}
+		if !info.Fields[2].Version {
+			t.Errorf("Expected Version field, got %+v", info.Fields[2])
+		}
+
+		if err := orm.NewOrmc().GenerateForStruct("Versioned", "models.go"); err != nil {
+			t.Fatalf("Failed to generate code for Versioned: %v", err)
+		}
+		outFile := "models_orm.go"
+		contentBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		defer os.Remove(outFile)
+		if !strings.Contains(string(contentBytes), "{Field: _schemaVersioned[2], Version: true},") {
+			t.Errorf("Generated file missing version SchemaExt:\n%s", contentBytes)
+		}
+	})
+
+	t.Run("Soft Delete Tag", func(t *testing.T) {
+		info, err := orm.NewOrmc().ParseStruct("SoftDeleted", "models.go")
+		if err != nil {
+			t.Fatalf("ParseStruct failed: %v", err)
+		}
+		if !info.Fields[1].SoftDelete {
+			t.Errorf("Expected SoftDelete field, got %+v", info.Fields[1])
+		}
+	})
+
+	t.Run("Timestamp Tags", func(t *testing.T) {
+		if err := orm.NewOrmc().GenerateForStruct("Timestamped", "models.go"); err != nil {
+			t.Fatalf("Failed to generate code for Timestamped: %v", err)
+		}
+		outFile := "models_orm.go"
+		contentBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		defer os.Remove(outFile)
+		for _, expected := range []string{
+			"{Field: _schemaTimestamped[1], Created: true},",
+			"{Field: _schemaTimestamped[2], Updated: true},",
+		} {
+			if !strings.Contains(string(contentBytes), expected) {
+				t.Errorf("Generated file missing %s:\n%s", expected, contentBytes)
+			}
+		}
+	})
+
+	t.Run("Named Scopes", func(t *testing.T) {
+		info, err := orm.NewOrmc().ParseStruct("Scoped", "models.go")
+		if err != nil {
+			t.Fatalf("ParseStruct failed: %v", err)
+		}
+		if strings.Join(info.Scopes, ",") != "Active,Newest" {
+			t.Errorf("Expected scopes [Active Newest], got %v", info.Scopes)
+		}
+
+		if err := orm.NewOrmc().GenerateForStruct("Scoped", "models.go"); err != nil {
+			t.Fatalf("Failed to generate code for Scoped: %v", err)
+		}
+		outFile := "models_orm.go"
+		contentBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		defer os.Remove(outFile)
+		for _, expected := range []string{
+			"var ScopedScope = struct {",
+			"\tActive func(*orm.QB) *orm.QB",
+			"\tActive: (&Scoped{}).ScopeActive,",
+			"\tNewest: (&Scoped{}).ScopeNewest,",
+		} {
+			if !strings.Contains(string(contentBytes), expected) {
+				t.Errorf("Generated file missing %q:\n%s", expected, contentBytes)
+			}
+		}
+	})
+
+	t.Run("View Directive", func(t *testing.T) {
+		tmp := t.TempDir()
+		modelFile := filepath.Join(tmp, "model.go")
+		src := `package app
+
+// ormc:view name=open_tasks as="SELECT id, title FROM task WHERE status = 'open';"
+type OpenTask struct {
+	ID    int64
+	Title string ` + "`" + `db:"index"` + "`" + `
+}
+
+// ormc:view
+type TaskStat struct {
+	Status string
+	N      int64
+}
+`
+		if err := os.WriteFile(modelFile, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		o := orm.NewOrmc()
+		open, err := o.ParseStruct("OpenTask", modelFile)
+		if err != nil {
+			t.Fatalf("ParseStruct failed: %v", err)
+		}
+		if !open.View || open.ModelName != "open_tasks" || open.ViewQuery != "SELECT id, title FROM task WHERE status = 'open'" {
+			t.Errorf("Unexpected view info: %+v", open)
+		}
+
+		// Without as=, the body comes from <view name>.sql next to the model
+		if _, err := o.ParseStruct("TaskStat", modelFile); err == nil {
+			t.Error("Expected error for a view without as= or .sql file")
+		}
+		body := "SELECT status, COUNT(*) AS n\nFROM task\nGROUP BY status;\n"
+		if err := os.WriteFile(filepath.Join(tmp, "task_stat.sql"), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		stat, err := o.ParseStruct("TaskStat", modelFile)
+		if err != nil {
+			t.Fatalf("ParseStruct failed: %v", err)
+		}
+		if stat.ViewQuery != "SELECT status, COUNT(*) AS n\nFROM task\nGROUP BY status" {
+			t.Errorf("Unexpected view query from .sql file: %q", stat.ViewQuery)
+		}
+
+		if err := o.GenerateForFile([]orm.StructInfo{open, stat}, modelFile); err != nil {
+			t.Fatalf("GenerateForFile failed: %v", err)
+		}
+		content, err := os.ReadFile(filepath.Join(tmp, "model_orm.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{
+			"\treturn \"open_tasks\"",
+			"func (m *OpenTask) ViewQuery() string {\n\treturn \"SELECT id, title FROM task WHERE status = 'open'\"",
+			"\treturn \"SELECT status, COUNT(*) AS n\\nFROM task\\nGROUP BY status\"",
+			"func ReadAllOpenTask(qb *orm.QB) ([]*OpenTask, error) {",
+		} {
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("Generated file missing %q:\n%s", expected, content)
+			}
+		}
+		if strings.Contains(string(content), "func (m *OpenTask) Indexes()") {
+			t.Error("Views must not declare indexes")
+		}
+
+		if snap := o.Snapshot(map[string]orm.StructInfo{"OpenTask": open}); len(snap.Tables) != 0 {
+			t.Errorf("Expected views left out of the schema snapshot, got %+v", snap.Tables)
+		}
+	})
+
+	t.Run("Invalid Role Tags", func(t *testing.T) {
+		cases := map[string]string{
+			"string version": "V string `db:\"version\"`",
+			"two versions":   "V int64 `db:\"version\"`\n\tW int64 `db:\"version\"`",
+			"int deleted_at": "D int `db:\"deleted_at\"`",
+			"two deleted_at": "D int64 `db:\"deleted_at\"`\n\tE int64 `db:\"deleted_at\"`",
+			"int32 created":  "C int32 `db:\"created\"`",
+			"two updated":    "U int64 `db:\"updated\"`\n\tV int64 `db:\"updated\"`",
+			"same field":     "C int64 `db:\"created,updated\"`",
+		}
+		tmp := t.TempDir()
+		for name, field := range cases {
+			t.Run(name, func(t *testing.T) {
+				path := tmp + "/model.go"
+				src := "package app\n\ntype Bad struct {\n\tID string\n\t" + field + "\n}\n"
+				if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := orm.NewOrmc().ParseStruct("Bad", path); err == nil {
+					t.Errorf("Expected error for %s", field)
+				}
+			})
+		}
+	})
+
 	t.Run("Bad AutoInc", func(t *testing.T) {
 		err := orm.NewOrmc().GenerateForStruct("BadAutoInc", "models.go")
 		if err == nil || !strings.Contains(err.Error(), "autoincrement not allowed on FieldText") {
@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"errors"
+
 	"github.com/tinywasm/fmt"
 	"github.com/tinywasm/orm"
 )
@@ -63,10 +65,46 @@ func (m *MockExecutor) Close() error {
 
 type MockScanner struct {
 	ScanErr error
+	Vals    []any // copied into dest by position when set
 }
 
 func (m *MockScanner) Scan(dest ...any) error {
-	return m.ScanErr
+	if m.ScanErr != nil {
+		return m.ScanErr
+	}
+	for i := range m.Vals {
+		if i < len(dest) {
+			assign(dest[i], m.Vals[i])
+		}
+	}
+	return nil
+}
+
+// assign stores v through dest for the pointer types used by the fixtures.
+func assign(dest, v any) {
+	switch d := dest.(type) {
+	case *int64:
+		*d = v.(int64)
+	case *int:
+		*d = v.(int)
+	case *string:
+		*d = v.(string)
+	case *any:
+		*d = v
+	}
+}
+
+// MockResultExecutor is a MockExecutor that also implements orm.ResultExecutor.
+type MockResultExecutor struct {
+	MockExecutor
+	Result orm.Result
+}
+
+func (m *MockResultExecutor) ExecResult(query string, args ...any) (orm.Result, error) {
+	if err := m.Exec(query, args...); err != nil {
+		return orm.Result{}, err
+	}
+	return m.Result, nil
 }
 
 type MockRows struct {
@@ -75,6 +113,7 @@ type MockRows struct {
 	ScanErr  error
 	CloseErr error
 	ErrVal   error
+	Closed   bool
 }
 
 func (m *MockRows) Next() bool {
@@ -90,6 +129,7 @@ func (m *MockRows) Scan(dest ...any) error {
 }
 
 func (m *MockRows) Close() error {
+	m.Closed = true
 	return m.CloseErr
 }
 
@@ -97,6 +137,39 @@ func (m *MockRows) Err() error {
 	return m.ErrVal
 }
 
+// MockColumnRows is an orm.ColumnRows returning Data row by row, with
+// values copied into dest by position.
+type MockColumnRows struct {
+	MockRows
+	Cols []string
+	Data [][]any
+}
+
+func (m *MockColumnRows) Next() bool {
+	m.Count = len(m.Data)
+	return m.MockRows.Next()
+}
+
+func (m *MockColumnRows) Scan(dest ...any) error {
+	for i, v := range m.Data[m.Current-1] {
+		assign(dest[i], v)
+	}
+	return nil
+}
+
+func (m *MockColumnRows) Columns() ([]string, error) { return m.Cols, nil }
+
+// MockRegionTotal is a result row (fmt.Fielder without ModelName).
+type MockRegionTotal struct {
+	Region string
+	Total  int64
+}
+
+func (m *MockRegionTotal) Schema() []fmt.Field {
+	return []fmt.Field{{Name: "region", Type: fmt.FieldText}, {Name: "total", Type: fmt.FieldInt}}
+}
+func (m *MockRegionTotal) Pointers() []any { return []any{&m.Region, &m.Total} }
+
 // MockModel is a mock implementation of the Model interface.
 type MockModel struct {
 	Table    string
@@ -119,6 +192,175 @@ func (m MockModel) Pointers() []any     {
 	return ptrs
 }
 
+// MockTenantPost is a typed model whose Validate requires tenant_id, as a
+// generated Validate does for a db:"not_null" field.
+type MockTenantPost struct {
+	ID       string
+	TenantID string
+}
+
+var _schemaMockTenantPost = []fmt.Field{
+	{Name: "id", Type: fmt.FieldText, DB: &fmt.FieldDB{PK: true}},
+	{Name: "tenant_id", Type: fmt.FieldText, NotNull: true},
+}
+
+func (m *MockTenantPost) ModelName() string   { return "post" }
+func (m *MockTenantPost) Schema() []fmt.Field { return _schemaMockTenantPost }
+func (m *MockTenantPost) Pointers() []any     { return []any{&m.ID, &m.TenantID} }
+func (m *MockTenantPost) Validate(action byte) error {
+	if m.TenantID == "" {
+		return errors.New("tenant_id required")
+	}
+	return nil
+}
+
+// MockTask is a typed model with real field pointers, for tests that depend
+// on the values read through fmt.ReadValues.
+type MockTask struct {
+	ID       int64
+	Title    string
+	Status   string
+	Priority int
+}
+
+var _schemaMockTask = []fmt.Field{
+	{Name: "id", Type: fmt.FieldInt, DB: &fmt.FieldDB{PK: true, AutoInc: true}},
+	{Name: "title", Type: fmt.FieldText},
+	{Name: "status", Type: fmt.FieldText},
+	{Name: "priority", Type: fmt.FieldInt},
+}
+
+var _schemaExtMockTask = []orm.FieldExt{
+	{Field: _schemaMockTask[0]},
+	{Field: _schemaMockTask[1]},
+	{Field: _schemaMockTask[2], Default: "'pending'"},
+	{Field: _schemaMockTask[3], Default: "3"},
+}
+
+func (m *MockTask) ModelName() string         { return "task" }
+func (m *MockTask) Schema() []fmt.Field       { return _schemaMockTask }
+func (m *MockTask) SchemaExt() []orm.FieldExt { return _schemaExtMockTask }
+func (m *MockTask) Pointers() []any {
+	return []any{&m.ID, &m.Title, &m.Status, &m.Priority}
+}
+
+// MockHookTask is a MockTask implementing every lifecycle hook. Calls
+// records the hooks run; the hook named in Fail returns an error.
+type MockHookTask struct {
+	MockTask
+	Calls  []string
+	Fail   string
+	SeenDB *orm.DB
+}
+
+func (m *MockHookTask) hook(name string, db *orm.DB) error {
+	m.Calls = append(m.Calls, name)
+	m.SeenDB = db
+	if m.Fail == name {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (m *MockHookTask) BeforeCreate(db *orm.DB) error { return m.hook("BeforeCreate", db) }
+func (m *MockHookTask) AfterCreate(db *orm.DB) error  { return m.hook("AfterCreate", db) }
+func (m *MockHookTask) BeforeUpdate(db *orm.DB) error { return m.hook("BeforeUpdate", db) }
+func (m *MockHookTask) AfterUpdate(db *orm.DB) error  { return m.hook("AfterUpdate", db) }
+func (m *MockHookTask) BeforeDelete(db *orm.DB) error { return m.hook("BeforeDelete", db) }
+func (m *MockHookTask) AfterDelete(db *orm.DB) error  { return m.hook("AfterDelete", db) }
+func (m *MockHookTask) AfterRead(db *orm.DB) error    { return m.hook("AfterRead", db) }
+
+// MockOpenTask is a view model over the task table.
+type MockOpenTask struct {
+	MockHookTask
+}
+
+func (m *MockOpenTask) ModelName() string { return "open_tasks" }
+func (m *MockOpenTask) ViewQuery() string {
+	return "SELECT id, title, status, priority FROM task WHERE status = 'open'"
+}
+
+// MockDoc is a typed model with a db:"version" optimistic-lock field.
+type MockDoc struct {
+	ID      int64
+	Body    string
+	Version int64
+}
+
+var _schemaMockDoc = []fmt.Field{
+	{Name: "id", Type: fmt.FieldInt, DB: &fmt.FieldDB{PK: true}},
+	{Name: "body", Type: fmt.FieldText},
+	{Name: "version", Type: fmt.FieldInt},
+}
+
+var _schemaExtMockDoc = []orm.FieldExt{
+	{Field: _schemaMockDoc[0]},
+	{Field: _schemaMockDoc[1]},
+	{Field: _schemaMockDoc[2], Version: true},
+}
+
+func (m *MockDoc) ModelName() string         { return "doc" }
+func (m *MockDoc) Schema() []fmt.Field       { return _schemaMockDoc }
+func (m *MockDoc) SchemaExt() []orm.FieldExt { return _schemaExtMockDoc }
+func (m *MockDoc) Pointers() []any {
+	return []any{&m.ID, &m.Body, &m.Version}
+}
+
+// MockNote is a typed model with a db:"deleted_at" soft-delete field.
+type MockNote struct {
+	ID        int64
+	Text      string
+	DeletedAt int64
+}
+
+var _schemaMockNote = []fmt.Field{
+	{Name: "id", Type: fmt.FieldInt, DB: &fmt.FieldDB{PK: true}},
+	{Name: "text", Type: fmt.FieldText},
+	{Name: "deleted_at", Type: fmt.FieldInt},
+}
+
+var _schemaExtMockNote = []orm.FieldExt{
+	{Field: _schemaMockNote[0]},
+	{Field: _schemaMockNote[1]},
+	{Field: _schemaMockNote[2], SoftDelete: true},
+}
+
+func (m *MockNote) ModelName() string         { return "note" }
+func (m *MockNote) Schema() []fmt.Field       { return _schemaMockNote }
+func (m *MockNote) SchemaExt() []orm.FieldExt { return _schemaExtMockNote }
+func (m *MockNote) Pointers() []any {
+	return []any{&m.ID, &m.Text, &m.DeletedAt}
+}
+
+// MockPost is a typed model with db:"created" and db:"updated" timestamps.
+type MockPost struct {
+	ID        int64
+	Title     string
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+var _schemaMockPost = []fmt.Field{
+	{Name: "id", Type: fmt.FieldInt, DB: &fmt.FieldDB{PK: true}},
+	{Name: "title", Type: fmt.FieldText},
+	{Name: "created_at", Type: fmt.FieldInt},
+	{Name: "updated_at", Type: fmt.FieldInt},
+}
+
+var _schemaExtMockPost = []orm.FieldExt{
+	{Field: _schemaMockPost[0]},
+	{Field: _schemaMockPost[1]},
+	{Field: _schemaMockPost[2], Created: true},
+	{Field: _schemaMockPost[3], Updated: true},
+}
+
+func (m *MockPost) ModelName() string         { return "post" }
+func (m *MockPost) Schema() []fmt.Field       { return _schemaMockPost }
+func (m *MockPost) SchemaExt() []orm.FieldExt { return _schemaExtMockPost }
+func (m *MockPost) Pointers() []any {
+	return []any{&m.ID, &m.Title, &m.CreatedAt, &m.UpdatedAt}
+}
+
 // MockTxExecutor ...
 type MockTxExecutor struct {
 	MockExecutor
@@ -153,3 +395,82 @@ func (m *MockTxBoundExecutor) Rollback() error {
 	m.RollbackCalled = true
 	return m.RollbackErr
 }
+
+// MockSavepointExecutor is a MockTxBoundExecutor that also implements
+// orm.SavepointExecutor. Log records the savepoint calls in order.
+type MockSavepointExecutor struct {
+	MockTxBoundExecutor
+	Log []string
+}
+
+func (m *MockSavepointExecutor) Savepoint(name string) error {
+	m.Log = append(m.Log, "savepoint "+name)
+	return nil
+}
+
+func (m *MockSavepointExecutor) RollbackTo(name string) error {
+	m.Log = append(m.Log, "rollback "+name)
+	return nil
+}
+
+func (m *MockSavepointExecutor) Release(name string) error {
+	m.Log = append(m.Log, "release "+name)
+	return nil
+}
+
+// MockSavepointTxExecutor is a TxExecutor whose transactions support savepoints.
+type MockSavepointTxExecutor struct {
+	MockExecutor
+	Bound *MockSavepointExecutor
+}
+
+func (m *MockSavepointTxExecutor) BeginTx() (orm.TxBoundExecutor, error) {
+	return m.Bound, nil
+}
+
+// MockReentrantTxExecutor is a TxExecutor whose transactions are TxExecutors
+// too, without savepoint support: BeginTx returns the executor itself.
+type MockReentrantTxExecutor struct {
+	MockTxBoundExecutor
+	BeginCalls int
+}
+
+func (m *MockReentrantTxExecutor) BeginTx() (orm.TxBoundExecutor, error) {
+	m.BeginCalls++
+	return m, nil
+}
+
+// MockTxOptionsExecutor is a MockTxExecutor that also implements
+// orm.TxOptionsExecutor, recording the options it was given.
+type MockTxOptionsExecutor struct {
+	MockTxExecutor
+	Opts *orm.TxOptions
+}
+
+func (m *MockTxOptionsExecutor) BeginTxWith(opts orm.TxOptions) (orm.TxBoundExecutor, error) {
+	m.Opts = &opts
+	return m.BeginTx()
+}
+
+// errBusy is the transient error reported by MockBusyExecutor.
+var errBusy = errors.New("database is locked (SQLITE_BUSY)")
+
+// MockBusyExecutor is a TxExecutor whose first Busy commits fail with errBusy,
+// which it classifies as retryable (orm.RetryClassifier).
+type MockBusyExecutor struct {
+	MockExecutor
+	Busy  int
+	Begun int
+}
+
+func (m *MockBusyExecutor) BeginTx() (orm.TxBoundExecutor, error) {
+	m.Begun++
+	bound := &MockTxBoundExecutor{}
+	if m.Busy > 0 {
+		m.Busy--
+		bound.CommitErr = errBusy
+	}
+	return bound, nil
+}
+
+func (m *MockBusyExecutor) Retryable(err error) bool { return errors.Is(err, errBusy) }
@@ -1,5 +1,7 @@
 package orm
 
+import "github.com/tinywasm/fmt"
+
 // TxBoundExecutor represents an executor bound to a transaction.
 type TxBoundExecutor interface {
 	Executor
@@ -13,27 +15,148 @@ type TxExecutor interface {
 	BeginTx() (TxBoundExecutor, error)
 }
 
+// SavepointExecutor is an optional TxBoundExecutor extension for engines with
+// savepoints (SAVEPOINT, ROLLBACK TO SAVEPOINT, RELEASE SAVEPOINT). It lets
+// Tx calls nest.
+type SavepointExecutor interface {
+	TxBoundExecutor
+	Savepoint(name string) error
+	RollbackTo(name string) error
+	Release(name string) error
+}
+
+// IsolationLevel is the transaction isolation level requested in TxOptions.
+type IsolationLevel int
+
+const (
+	LevelDefault IsolationLevel = iota // the engine's default
+	LevelReadUncommitted
+	LevelReadCommitted
+	LevelRepeatableRead
+	LevelSerializable
+)
+
+// TxOptions configures a transaction started by TxWith.
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+}
+
+// TxOptionsExecutor is an optional TxExecutor extension for engines that can
+// start transactions with TxOptions.
+type TxOptionsExecutor interface {
+	TxExecutor
+	BeginTxWith(opts TxOptions) (TxBoundExecutor, error)
+}
+
+// RollbackError is returned when a transaction or savepoint failed and rolling
+// it back failed too. errors.Is and errors.As match both errors.
+type RollbackError struct {
+	Err         error // the error that caused the rollback
+	RollbackErr error
+}
+
+func (e *RollbackError) Error() string {
+	return e.Err.Error() + " (rollback: " + e.RollbackErr.Error() + ")"
+}
+
+func (e *RollbackError) Unwrap() []error { return []error{e.Err, e.RollbackErr} }
+
 // Tx executes a function within a transaction.
+// Model hooks run inside fn receive the transactional *DB.
+//
+// An error from fn rolls the transaction back and is returned, joined with
+// the rollback error if that failed too (see RollbackError). A panic in fn
+// rolls back and panics again.
+//
+// Called on a transactional *DB, Tx opens a savepoint instead: an error from
+// fn rolls back to it, leaving the outer transaction running, and success
+// releases it. Executors that do not implement SavepointExecutor return
+// ErrNoTxSupport for nested calls.
 func (db *DB) Tx(fn func(tx *DB) error) error {
+	return db.TxWith(TxOptions{}, fn)
+}
+
+// TxWith is Tx with options for the transaction. Non-default options need an
+// executor implementing TxOptionsExecutor; otherwise ErrNoTxSupport is
+// returned. Nested calls run in a savepoint of the outer transaction and
+// ignore opts.
+func (db *DB) TxWith(opts TxOptions, fn func(tx *DB) error) error {
+	if db.txDepth > 0 {
+		return db.savepoint(fn)
+	}
+
+	bound, err := db.beginTx(opts)
+	if err != nil {
+		return err
+	}
+
+	// The transactional DB keeps the settings of db; only the executor changes.
+	txDB := *db
+	txDB.exec = bound
+	txDB.txDepth = 1
+	txDB.replicas = nil
+
+	if err := run(fn, &txDB, bound.Rollback); err != nil {
+		return err
+	}
+
+	return bound.Commit()
+}
+
+func (db *DB) beginTx(opts TxOptions) (TxBoundExecutor, error) {
+	if opts != (TxOptions{}) {
+		optExec, ok := db.exec.(TxOptionsExecutor)
+		if !ok {
+			return nil, ErrNoTxSupport
+		}
+		return optExec.BeginTxWith(opts)
+	}
 	txExec, ok := db.exec.(TxExecutor)
+	if !ok {
+		return nil, ErrNoTxSupport
+	}
+	return txExec.BeginTx()
+}
+
+// savepoint runs fn in a savepoint of the current transaction, named after
+// the nesting depth.
+func (db *DB) savepoint(fn func(tx *DB) error) error {
+	sp, ok := db.exec.(SavepointExecutor)
 	if !ok {
 		return ErrNoTxSupport
 	}
 
-	bound, err := txExec.BeginTx()
-	if err != nil {
+	name := fmt.Sprintf("sp_%d", db.txDepth)
+	if err := sp.Savepoint(name); err != nil {
 		return err
 	}
 
-	txDB := &DB{
-		exec:     bound,
-		compiler: db.compiler,
-	}
+	spDB := *db
+	spDB.txDepth++
 
-	if err := fn(txDB); err != nil {
-		bound.Rollback()
+	if err := run(fn, &spDB, func() error { return sp.RollbackTo(name) }); err != nil {
 		return err
 	}
 
-	return bound.Commit()
+	return sp.Release(name)
+}
+
+// run calls fn(tx) and calls rollback when fn fails or panics. Panics are
+// re-raised after the rollback.
+func run(fn func(tx *DB) error, tx *DB, rollback func() error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			return &RollbackError{Err: err, RollbackErr: rbErr}
+		}
+		return err
+	}
+	return nil
 }
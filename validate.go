@@ -15,3 +15,29 @@ func validateQuery(action Action, m fmt.Model) error {
 
 	return nil
 }
+
+// ValidationError wraps the error returned by a model's Validate method.
+// errors.Is(err, ErrValidation) reports true for it.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string        { return ErrValidation.Error() + ": " + e.Err.Error() }
+func (e *ValidationError) Unwrap() error        { return e.Err }
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// validate calls m.Validate(action) when m implements fmt.Validator and
+// validation is enabled on db.
+func (db *DB) validate(action byte, m fmt.Model) error {
+	if db.noValidate {
+		return nil
+	}
+	v, ok := m.(fmt.Validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(action); err != nil {
+		return &ValidationError{Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package orm
+
+// isZeroValue reports whether v, as returned by fmt.ReadValues, holds the zero
+// value of its type. Nil is zero; nested structs never are.
+func isZeroValue(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case int:
+		return x == 0
+	case int32:
+		return x == 0
+	case int64:
+		return x == 0
+	case uint:
+		return x == 0
+	case uint32:
+		return x == 0
+	case uint64:
+		return x == 0
+	case float32:
+		return x == 0
+	case float64:
+		return x == 0
+	case bool:
+		return !x
+	case []byte:
+		return len(x) == 0
+	}
+	return false
+}
+
+// setIntPtr stores v through an integer field pointer. It returns false when
+// ptr is not a pointer to a Go integer type.
+func setIntPtr(ptr any, v int64) bool {
+	switch p := ptr.(type) {
+	case *int:
+		*p = int(v)
+	case *int32:
+		*p = int32(v)
+	case *int64:
+		*p = v
+	case *uint:
+		*p = uint(v)
+	case *uint32:
+		*p = uint32(v)
+	case *uint64:
+		*p = uint64(v)
+	default:
+		return false
+	}
+	return true
+}
+
+// intValue returns v as int64 when v holds a Go integer type.
+func intValue(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64:
+		return x, true
+	case uint:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case uint64:
+		return int64(x), true
+	}
+	return 0, false
+}
+
+// setFieldValue stores v through a field pointer of the same kind: integers
+// through any integer pointer, other values only through a pointer to their
+// own type. It returns false when v does not fit ptr.
+func setFieldValue(ptr, v any) bool {
+	if i, ok := intValue(v); ok {
+		return setIntPtr(ptr, i)
+	}
+	switch p := ptr.(type) {
+	case *string:
+		x, ok := v.(string)
+		if ok {
+			*p = x
+		}
+		return ok
+	case *bool:
+		x, ok := v.(bool)
+		if ok {
+			*p = x
+		}
+		return ok
+	case *any:
+		*p = v
+		return true
+	case *float64:
+		x, ok := v.(float64)
+		if ok {
+			*p = x
+		}
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package orm
+
+import "github.com/tinywasm/fmt"
+
+// ViewModel is implemented by models backed by a database view rather than a
+// table. ModelName is the view name and ViewQuery its SELECT body.
+// Implementations are generated by ormc for // ormc:view structs.
+// Views are read with DB.Query like tables; writes fail with ErrReadOnlyView.
+type ViewModel interface {
+	fmt.Model
+	ViewQuery() string
+}
+
+// CreateView creates the view of m from m.ViewQuery().
+func (db *DB) CreateView(m ViewModel) error {
+	return db.viewDDL(ActionCreateView, m, m.ViewQuery())
+}
+
+// DropView drops the view of m.
+func (db *DB) DropView(m ViewModel) error {
+	return db.viewDDL(ActionDropView, m, "")
+}
+
+func (db *DB) viewDDL(action Action, m ViewModel, body string) error {
+	if err := validateQuery(action, m); err != nil {
+		return err
+	}
+	if action == ActionCreateView && body == "" {
+		return fmt.Err("view", m.ModelName(), "has an empty query")
+	}
+	q := Query{
+		Action:    action,
+		Table:     m.ModelName(),
+		ViewQuery: body,
+	}
+	plan, exec, err := db.compile(db.exec, q, m)
+	if err != nil {
+		return err
+	}
+	return exec.Exec(plan.Query, plan.Args...)
+}
+
+// writable returns ErrReadOnlyView for view models, which cannot be written.
+func writable(m fmt.Model) error {
+	if _, ok := m.(ViewModel); ok {
+		return ErrReadOnlyView
+	}
+	return nil
+}